@@ -1,24 +1,62 @@
 package cmdlr2
 
-import "sync"
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+)
 
+// ObjectsMap is the in-memory Storage used by default. Entries can expire and the map can be limited to a maximum
+// amount of entries, evicting the least recently used ones.
 type ObjectsMap struct {
-	mutex    sync.RWMutex
-	innerMap map[string]interface{}
+	mutex    sync.Mutex
+	innerMap map[string]*list.Element
+	// recent holds the *objectsEntry values, the most recently used one first
+	recent     *list.List
+	maxEntries int
+	lastSweep  time.Time
 }
 
+type objectsEntry struct {
+	key   string
+	value interface{}
+	// expires is zero for entries which never expire
+	expires time.Time
+}
+
+var _ AtomicStorage = (*ObjectsMap)(nil)
+
+// NewObjectsMap creates a new unlimited ObjectsMap
 func NewObjectsMap() *ObjectsMap {
+	return NewLRUObjectsMap(0)
+}
+
+// NewLRUObjectsMap creates a new ObjectsMap holding at most the given amount of entries, 0 means no limit
+func NewLRUObjectsMap(maxEntries int) *ObjectsMap {
 	return &ObjectsMap{
-		innerMap: map[string]interface{}{},
+		innerMap:   map[string]*list.Element{},
+		recent:     list.New(),
+		maxEntries: maxEntries,
+		lastSweep:  time.Now(),
 	}
 }
 
+func (e *objectsEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
 func (om *ObjectsMap) Get(key string) (interface{}, bool) {
-	om.mutex.RLock()
-	defer om.mutex.RUnlock()
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
 
-	v, ok := om.innerMap[key]
-	return v, ok
+	entry, ok := om.get(key, time.Now())
+	if !ok {
+		return nil, false
+	}
+	return entry.value, true
 }
 
 func (om *ObjectsMap) MustGet(key string) interface{} {
@@ -30,15 +68,180 @@ func (om *ObjectsMap) MustGet(key string) interface{} {
 }
 
 func (om *ObjectsMap) Set(key string, val interface{}) {
+	om.SetWithTTL(key, val, 0)
+}
+
+// SetWithTTL stores the given value until the given duration passed. A duration of 0 stores it without expiry.
+func (om *ObjectsMap) SetWithTTL(key string, val interface{}, ttl time.Duration) {
 	om.mutex.Lock()
 	defer om.mutex.Unlock()
 
-	om.innerMap[key] = val
+	now := time.Now()
+	om.sweep(now)
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = now.Add(ttl)
+	}
+	om.set(key, val, expires)
+}
+
+func (om *ObjectsMap) set(key string, val interface{}, expires time.Time) {
+	if element, ok := om.innerMap[key]; ok {
+		entry := element.Value.(*objectsEntry)
+		entry.value = val
+		entry.expires = expires
+		om.recent.MoveToFront(element)
+		return
+	}
+
+	om.innerMap[key] = om.recent.PushFront(&objectsEntry{key: key, value: val, expires: expires})
+	if om.maxEntries > 0 && om.recent.Len() > om.maxEntries {
+		om.remove(om.recent.Back())
+	}
+}
+
+// GetOrSet returns the value stored for the given key. If there is none, the given value is stored without expiry and
+// returned. Loaded reports whether the value was already stored.
+func (om *ObjectsMap) GetOrSet(key string, val interface{}) (actual interface{}, loaded bool) {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+
+	if entry, ok := om.get(key, time.Now()); ok {
+		return entry.value, true
+	}
+	om.set(key, val, time.Time{})
+	return val, false
+}
+
+// Update replaces the value stored for the given key by the result of the given function while holding the lock. The
+// function receives the current value and whether there is one. The expiry of an existing value is kept.
+func (om *ObjectsMap) Update(key string, update func(val interface{}, ok bool) interface{}) interface{} {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+
+	var current interface{}
+	var expires time.Time
+	entry, ok := om.get(key, time.Now())
+	if ok {
+		current, expires = entry.value, entry.expires
+	}
+
+	val := update(current, ok)
+	om.set(key, val, expires)
+	return val
+}
+
+// CompareAndSwap replaces the value stored for the given key if it equals old. The expiry of the value is kept. Like
+// sync.Map, it panics if old isn't comparable.
+func (om *ObjectsMap) CompareAndSwap(key string, old, new interface{}) bool {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+
+	entry, ok := om.get(key, time.Now())
+	if !ok || !equalValues(entry.value, old) {
+		return false
+	}
+	om.set(key, new, entry.expires)
+	return true
+}
+
+// equalValues compares two stored values. Values loaded by a FileStorage are compared by their encoding, values which
+// can't be compared using == like slices and maps are compared deeply.
+func equalValues(a, b interface{}) bool {
+	if rawA, ok := a.(json.RawMessage); ok {
+		rawB, ok := b.(json.RawMessage)
+		return ok && bytes.Equal(rawA, rawB)
+	}
+	if a == nil || b == nil {
+		return a == b
+	}
+	if reflect.TypeOf(a).Comparable() && reflect.TypeOf(b).Comparable() {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// get returns the entry stored for the given key, removing it if it expired. The lock has to be held.
+func (om *ObjectsMap) get(key string, now time.Time) (*objectsEntry, bool) {
+	element, ok := om.innerMap[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*objectsEntry)
+	if entry.expired(now) {
+		om.remove(element)
+		return nil, false
+	}
+	om.recent.MoveToFront(element)
+	return entry, true
+}
+
+// Range calls the given function for every value which didn't expire yet, the least recently used one first. It
+// iterates over a copy, so the function may modify the map. Returning false stops the iteration.
+func (om *ObjectsMap) Range(f func(key string, val interface{}) bool) {
+	for _, entry := range om.entries() {
+		if !f(entry.key, entry.value) {
+			return
+		}
+	}
+}
+
+// Len returns the amount of values which didn't expire yet
+func (om *ObjectsMap) Len() int {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+
+	now := time.Now()
+	length := 0
+	for _, element := range om.innerMap {
+		if !element.Value.(*objectsEntry).expired(now) {
+			length++
+		}
+	}
+	return length
 }
 
 func (om *ObjectsMap) Delete(key string) {
 	om.mutex.Lock()
 	defer om.mutex.Unlock()
 
-	delete(om.innerMap, key)
+	if element, ok := om.innerMap[key]; ok {
+		om.remove(element)
+	}
+}
+
+func (om *ObjectsMap) remove(element *list.Element) {
+	om.recent.Remove(element)
+	delete(om.innerMap, element.Value.(*objectsEntry).key)
+}
+
+// sweep removes the expired entries at most once a minute
+func (om *ObjectsMap) sweep(now time.Time) {
+	if now.Sub(om.lastSweep) < time.Minute {
+		return
+	}
+	om.lastSweep = now
+
+	for _, element := range om.innerMap {
+		if element.Value.(*objectsEntry).expired(now) {
+			om.remove(element)
+		}
+	}
+}
+
+// entries returns a copy of all entries which didn't expire yet
+func (om *ObjectsMap) entries() []objectsEntry {
+	om.mutex.Lock()
+	defer om.mutex.Unlock()
+
+	now := time.Now()
+	entries := make([]objectsEntry, 0, len(om.innerMap))
+	for element := om.recent.Back(); element != nil; element = element.Prev() {
+		if entry := element.Value.(*objectsEntry); !entry.expired(now) {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
 }
@@ -0,0 +1,131 @@
+package cmdlr2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArgumentType is a custom parameter type. Once registered on the router, parameters and flags can use its name as
+// their type and get parsed, validated and documented like the built-in types.
+type ArgumentType struct {
+	// Name is used as the Type of parameters and flags
+	Name ParamType
+	// Display names the type in usage errors and the help command like `must be a <display>`
+	Display string
+	// Parse converts the raw argument. It can return an *ArgumentError to replace the default error message.
+	Parse func(ctx *Ctx, raw string) (interface{}, error)
+	// Measure optionally returns the number checked against Param.Min and Param.Max
+	Measure func(value interface{}) float64
+	// Complete optionally returns suggestions for the given partial input which are offered by the autocompletion of
+	// slash commands
+	Complete func(ctx *Ctx, partial string) []string
+}
+
+// RegisterArgumentType registers the given custom parameter type. It fails if the name is already used by a built-in
+// or another registered type.
+func (r *Router) RegisterArgumentType(argumentType *ArgumentType) error {
+	if argumentType.Name == "" || argumentType.Parse == nil {
+		return fmt.Errorf("argument types need a name and a parse function")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := builtinParamTypes[argumentType.Name]; ok {
+		return fmt.Errorf("argument type %q is a built-in type", argumentType.Name)
+	}
+	if _, ok := r.argumentTypes[argumentType.Name]; ok {
+		return fmt.Errorf("argument type %q is already registered", argumentType.Name)
+	}
+
+	display := argumentType.Display
+	if display == "" {
+		display = string(argumentType.Name)
+	}
+	if r.argumentTypes == nil {
+		r.argumentTypes = map[ParamType]*paramConverter{}
+	}
+	r.argumentTypes[argumentType.Name] = &paramConverter{
+		display:  display,
+		parse:    argumentType.Parse,
+		measure:  argumentType.Measure,
+		complete: argumentType.Complete,
+	}
+	return nil
+}
+
+// UnknownTypeError is returned if a command is registered whose parameter or flag uses a type which is neither a
+// built-in nor a registered type
+type UnknownTypeError struct {
+	// Path is the space separated name of the command the parameter or flag belongs to
+	Path string
+	Name string
+	Type ParamType
+}
+
+func (e *UnknownTypeError) Error() string {
+	return fmt.Sprintf("`%s` of `%s` has the unknown type %q", e.Name, e.Path, e.Type)
+}
+
+// findUnknownType checks the types of the parameters and flags of the given commands and their sub commands. The
+// mutex of the router has to be held.
+func (r *Router) findUnknownType(path string, commands []*Command) *UnknownTypeError {
+	known := func(paramType ParamType) bool {
+		if _, ok := builtinParamTypes[paramType]; ok {
+			return true
+		}
+		_, ok := r.argumentTypes[paramType]
+		return ok
+	}
+
+	for _, command := range commands {
+		commandPath := strings.TrimSpace(path + " " + command.Name)
+		for _, param := range command.Params {
+			if !known(param.paramType()) {
+				return &UnknownTypeError{Path: commandPath, Name: param.Name, Type: param.Type}
+			}
+		}
+		for _, flag := range command.Flags {
+			if !known(flag.paramType()) {
+				return &UnknownTypeError{Path: commandPath, Name: flag.Name, Type: flag.Type}
+			}
+		}
+		if err := r.findUnknownType(commandPath, command.SubCommands); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// paramConverter returns the converter of the given built-in or custom type. It can be called on a nil router to
+// only look up the built-in types.
+func (r *Router) paramConverter(paramType ParamType) (*paramConverter, bool) {
+	if converter, ok := builtinParamTypes[paramType]; ok {
+		return converter, true
+	}
+	if r == nil {
+		return nil, false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	converter, ok := r.argumentTypes[paramType]
+	return converter, ok
+}
+
+func (ctx *Ctx) paramConverter(paramType ParamType) (*paramConverter, bool) {
+	var r *Router
+	if ctx != nil {
+		r = ctx.Router
+	}
+	return r.paramConverter(paramType)
+}
+
+// paramDisplay returns the display name of the given type
+func (r *Router) paramDisplay(paramType ParamType) string {
+	if converter, ok := r.paramConverter(paramType); ok {
+		return converter.display
+	}
+	return string(paramType)
+}
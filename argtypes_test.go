@@ -0,0 +1,171 @@
+package cmdlr2_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zackartz/cmdlr2"
+	"github.com/zackartz/cmdlr2/cmdlrtest"
+)
+
+type version struct {
+	major, minor int
+}
+
+var regions = []string{"eu-west", "eu-central", "us-east", "us-west"}
+
+func newTypesHarness(t *testing.T) *cmdlrtest.Harness {
+	h := newHarness()
+
+	err := h.Router.RegisterArgumentType(&cmdlr2.ArgumentType{
+		Name:    "version",
+		Display: "version like 1.2",
+		Parse: func(ctx *cmdlr2.Ctx, raw string) (interface{}, error) {
+			parts := strings.Split(raw, ".")
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid version")
+			}
+			major, err := strconv.Atoi(parts[0])
+			if err != nil {
+				return nil, err
+			}
+			minor, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, err
+			}
+			return version{major, minor}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = h.Router.RegisterArgumentType(&cmdlr2.ArgumentType{
+		Name:    "region",
+		Display: "region",
+		Parse: func(ctx *cmdlr2.Ctx, raw string) (interface{}, error) {
+			for _, region := range regions {
+				if region == raw {
+					return region, nil
+				}
+			}
+			return nil, &cmdlr2.ArgumentError{Message: "is no known region"}
+		},
+		Complete: func(ctx *cmdlr2.Ctx, partial string) []string {
+			var suggestions []string
+			for _, region := range regions {
+				if strings.HasPrefix(region, partial) {
+					suggestions = append(suggestions, region)
+				}
+			}
+			return suggestions
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name: "deploy",
+		Params: []*cmdlr2.Param{
+			{Name: "version", Type: "version"},
+			{Name: "region", Type: "region", Optional: true, Default: "eu-west"},
+		},
+		Handler: func(ctx *cmdlr2.Ctx) {
+			v := ctx.Params["version"].(version)
+			_ = ctx.ResponseText(fmt.Sprintf("%d.%d to %s", v.major, v.minor, ctx.Params.String("region")))
+		},
+	})
+	return h
+}
+
+func TestArgumentTypes(t *testing.T) {
+	h := newTypesHarness(t)
+
+	assertReplies(t, "!deploy 1.2", send(h, "!deploy 1.2"), "1.2 to eu-west")
+	assertReplies(t, "!deploy 1.2 us-east", send(h, "!deploy 1.2 us-east"), "1.2 to us-east")
+	assertReplies(t, "!deploy 1", send(h, "!deploy 1"),
+		"Invalid usage: invalid argument `version`: must be a version like 1.2.\nUsage: `!deploy <version> [region]`")
+	assertReplies(t, "!deploy 1.2 mars", send(h, "!deploy 1.2 mars"),
+		"Invalid usage: invalid argument `region`: is no known region.\nUsage: `!deploy <version> [region]`")
+
+	if err := h.Router.RegisterArgumentType(&cmdlr2.ArgumentType{Name: "region", Parse: nil}); err == nil {
+		t.Error("expected an argument type without a parse function to be rejected")
+	}
+	parse := func(ctx *cmdlr2.Ctx, raw string) (interface{}, error) { return raw, nil }
+	if err := h.Router.RegisterArgumentType(&cmdlr2.ArgumentType{Name: "region", Parse: parse}); err == nil {
+		t.Error("expected a duplicate argument type to be rejected")
+	}
+	if err := h.Router.RegisterArgumentType(&cmdlr2.ArgumentType{Name: cmdlr2.ParamInt, Parse: parse}); err == nil {
+		t.Error("expected a built-in argument type to be rejected")
+	}
+}
+
+func TestUnknownArgumentTypes(t *testing.T) {
+	h := newHarness()
+	deploy := &cmdlr2.Command{Name: "deploy", Params: []*cmdlr2.Param{{Name: "version", Type: "version"}}}
+	config := &cmdlr2.Command{Name: "config", SubCommands: []*cmdlr2.Command{
+		{Name: "set", Flags: []*cmdlr2.Flag{{Name: "region", Type: "region"}}},
+	}}
+
+	var unknownType *cmdlr2.UnknownTypeError
+	err := h.Router.RegisterCMD(deploy)
+	if !errors.As(err, &unknownType) || unknownType.Path != "deploy" || unknownType.Name != "version" ||
+		unknownType.Type != "version" {
+		t.Errorf("expected the unknown parameter type to be rejected, got %v", err)
+	}
+	err = h.Router.RegisterCMD(config)
+	if !errors.As(err, &unknownType) || unknownType.Path != "config set" || unknownType.Name != "region" ||
+		unknownType.Type != "region" {
+		t.Errorf("expected the unknown flag type of the sub command to be rejected, got %v", err)
+	}
+	if h.Router.GetCmd("deploy") != nil || h.Router.GetCmd("config") != nil {
+		t.Error("expected the commands not to be registered")
+	}
+
+	parse := func(ctx *cmdlr2.Ctx, raw string) (interface{}, error) { return raw, nil }
+	if err := h.Router.RegisterArgumentType(&cmdlr2.ArgumentType{Name: "version", Parse: parse}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Router.RegisterCMD(deploy); err != nil {
+		t.Errorf("expected the command to be registered once its type is, got %v", err)
+	}
+
+	err = h.Router.RegisterCMD(&cmdlr2.Command{
+		Name:   "say",
+		Params: []*cmdlr2.Param{{Name: "text"}},
+		Handler: func(ctx *cmdlr2.Ctx) {
+			_ = ctx.ResponseText(ctx.Params.String("text"))
+		},
+	})
+	if err != nil {
+		t.Errorf("expected a parameter without a type to be a string, got %v", err)
+	}
+	assertReplies(t, "!say hi", send(h, "!say hi"), "hi")
+}
+
+func TestArgumentTypeAutocomplete(t *testing.T) {
+	h := newTypesHarness(t)
+
+	options := h.Router.ApplicationCommands()[0].Options
+	if options[0].Autocomplete || !options[1].Autocomplete {
+		t.Errorf("expected only the region option to be autocompleted")
+	}
+
+	suggestions := h.Autocomplete("deploy", cmdlrtest.Option("version", "1.2"), cmdlrtest.Focused("region", "eu"))
+	var names []string
+	for _, suggestion := range suggestions {
+		names = append(names, suggestion.Name)
+	}
+	if strings.Join(names, ",") != "eu-west,eu-central" {
+		t.Errorf("unexpected suggestions %v", names)
+	}
+
+	replies := h.Interact("deploy", cmdlrtest.Option("version", "2.0"))
+	if len(replies) != 1 || replies[0].Content != "2.0 to eu-west" {
+		t.Errorf("unexpected replies %v", replies)
+	}
+}
@@ -6,9 +6,13 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 var (
+	// Deprecated: RegexArguments isn't used anymore, arguments are split by a tokenizer supporting single, double and
+	// smart quotes as well as escaped quotes
 	RegexArguments = regexp.MustCompile("(\"[^\"]+\"|[^\\s]+)")
 
 	RegexUserMention = regexp.MustCompile("<@!?(\\d+)>")
@@ -17,6 +21,8 @@ var (
 
 	RegexChannelMention = regexp.MustCompile("<#(\\d+)>")
 
+	RegexEmoji = regexp.MustCompile("<(a?):(\\w+):(\\d+)>")
+
 	RegexBigCodeblock = regexp.MustCompile("(?s)\\n*```(?:([\\w.\\-]*)\\n)?(.*)```")
 
 	RegexSmallCodeblock = regexp.MustCompile("(?s)\\n*`(.*)`")
@@ -342,6 +348,9 @@ var (
 
 type Argument struct {
 	raw string
+	// start and end are the byte offsets of the argument in the raw string of the arguments, including its quotes
+	start int
+	end   int
 }
 
 type Arguments struct {
@@ -354,23 +363,114 @@ type Codeblock struct {
 	Content  string
 }
 
+// ParseArguments splits the given string into arguments. Arguments are separated by whitespace unless it is quoted
+// using double quotes, single quotes or smart quotes. Quotes and backslashes can be escaped using a backslash, quotes
+// which are never closed are treated as regular characters.
 func ParseArguments(raw string) *Arguments {
-	rawArguments := RegexArguments.FindAllString(raw, -1)
-	arguments := make([]*Argument, len(rawArguments))
+	return &Arguments{
+		raw:  raw,
+		args: tokenize(raw),
+	}
+}
 
-	for index, rawArgument := range rawArguments {
-		rawArgument = StringTrimPreSuffix(rawArgument, "\"")
-		arguments[index] = &Argument{
-			raw: rawArgument,
+// closingQuotes maps the quotes an argument can start with to the quotes closing it
+var closingQuotes = map[rune]string{
+	'"':  "\"",
+	'\'': "'",
+	'“':  "”“",
+	'„':  "“”",
+	'‘':  "’‘",
+}
+
+func tokenize(raw string) []*Argument {
+	var arguments []*Argument
+	for i := 0; i < len(raw); {
+		r, size := utf8.DecodeRuneInString(raw[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
 		}
+
+		argument := readArgument(raw, i)
+		arguments = append(arguments, argument)
+		i = argument.end
 	}
+	return arguments
+}
 
-	return &Arguments{
-		raw:  raw,
-		args: arguments,
+// readArgument reads the argument starting at the given offset. Quotes only start a quoted section at the beginning of
+// an argument so apostrophes in words like `don't` don't need to be escaped.
+func readArgument(raw string, start int) *Argument {
+	var value strings.Builder
+	i := start
+
+	if r, size := utf8.DecodeRuneInString(raw[i:]); closingQuotes[r] != "" {
+		if end, ok := readQuoted(raw, i+size, closingQuotes[r], &value); ok {
+			i = end
+		} else {
+			// Treat an unterminated quote as a regular character
+			value.Reset()
+			value.WriteRune(r)
+			i += size
+		}
+	}
+
+	for i < len(raw) {
+		r, size := utf8.DecodeRuneInString(raw[i:])
+		if unicode.IsSpace(r) {
+			break
+		}
+		if r == '\\' && i+size < len(raw) {
+			next, nextSize := utf8.DecodeRuneInString(raw[i+size:])
+			if next == '\\' || closingQuotes[next] != "" || strings.ContainsRune("”’", next) || unicode.IsSpace(next) {
+				value.WriteRune(next)
+				i += size + nextSize
+				continue
+			}
+		}
+		value.WriteRune(r)
+		i += size
+	}
+
+	return &Argument{
+		raw:   value.String(),
+		start: start,
+		end:   i,
 	}
 }
 
+// readQuoted reads a quoted section starting after the opening quote up to one of the given closing quotes. It returns
+// the offset after the closing quote and false if the section is never closed.
+func readQuoted(raw string, i int, closing string, value *strings.Builder) (int, bool) {
+	for i < len(raw) {
+		r, size := utf8.DecodeRuneInString(raw[i:])
+		if strings.ContainsRune(closing, r) {
+			return i + size, true
+		}
+		if r == '\\' && i+size < len(raw) {
+			next, nextSize := utf8.DecodeRuneInString(raw[i+size:])
+			if next == '\\' || strings.ContainsRune(closing, next) {
+				value.WriteRune(next)
+				i += size + nextSize
+				continue
+			}
+		}
+		value.WriteRune(r)
+		i += size
+	}
+	return i, false
+}
+
+// quoteArgument quotes the given value if necessary so it is parsed as a single argument
+func quoteArgument(value string) string {
+	first, _ := utf8.DecodeRuneInString(value)
+	if value != "" && closingQuotes[first] == "" && !strings.ContainsRune(value, '\\') &&
+		strings.IndexFunc(value, unicode.IsSpace) < 0 {
+		return value
+	}
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(value) + "\""
+}
+
 func (a *Arguments) Raw() string {
 	return a.raw
 }
@@ -401,11 +501,34 @@ func (a *Arguments) Remove(n int) {
 
 	a.args = append(a.args[:n], a.args[n+1:]...)
 
-	raw := ""
-	for _, argument := range a.args {
-		raw += argument.raw + " "
+	// Rebuild the raw string from the original spans of the remaining arguments to keep their quotes
+	var raw strings.Builder
+	for index, argument := range a.args {
+		if index > 0 {
+			raw.WriteString(" ")
+		}
+		span := a.raw[argument.start:argument.end]
+		argument.start = raw.Len()
+		raw.WriteString(span)
+		argument.end = raw.Len()
+	}
+	a.raw = raw.String()
+}
+
+// rawFrom returns the raw string starting at the n-th argument
+func (a *Arguments) rawFrom(n int) string {
+	if n >= len(a.args) {
+		return ""
+	}
+	return a.raw[a.args[n].start:]
+}
+
+// from returns the arguments starting at the n-th argument
+func (a *Arguments) from(n int) *Arguments {
+	if n == 0 {
+		return a
 	}
-	a.raw = strings.TrimSpace(raw)
+	return ParseArguments(a.rawFrom(n))
 }
 
 // AsCodeblock parses the given arguments as a codeblock
@@ -448,6 +571,16 @@ func (arg *Argument) Raw() string {
 	return arg.raw
 }
 
+// Start returns the byte offset at which the argument including its quotes starts in the raw string of the arguments
+func (arg *Argument) Start() int {
+	return arg.start
+}
+
+// End returns the byte offset after the end of the argument including its quotes in the raw string of the arguments
+func (arg *Argument) End() int {
+	return arg.end
+}
+
 // AsBool parses the given argument into a boolean
 func (arg *Argument) AsBool() (bool, error) {
 	return strconv.ParseBool(arg.raw)
@@ -0,0 +1,68 @@
+package cmdlr2_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zackartz/cmdlr2"
+)
+
+func TestParseArguments(t *testing.T) {
+	tests := map[string][]string{
+		`a b  c`:                   {"a", "b", "c"},
+		"a\nb\tc":                  {"a", "b", "c"},
+		`"a b" c`:                  {"a b", "c"},
+		`'a b' c`:                  {"a b", "c"},
+		`“a b” c`:                  {"a b", "c"},
+		`‘a b’ c`:                  {"a b", "c"},
+		`"say \"hi\"" x`:           {`say "hi"`, "x"},
+		`"it's" don't`:             {"it's", "don't"},
+		`"a b`:                     {`"a`, "b"},
+		`'tis fine`:                {"'tis", "fine"},
+		`a\ b c`:                   {"a b", "c"},
+		`C:\Users\bot`:             {`C:\Users\bot`},
+		`"" x`:                     {"", "x"},
+		`"a"b c`:                   {"ab", "c"},
+		`"back\\slash"`:            {`back\slash`},
+		"\"multi\nline\" rest":     {"multi\nline", "rest"},
+		`  leading and trailing  `: {"leading", "and", "trailing"},
+	}
+	for input, expected := range tests {
+		args := cmdlr2.ParseArguments(input)
+		var actual []string
+		for i := 0; i < args.Amount(); i++ {
+			actual = append(actual, args.Get(i).Raw())
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%q: expected %q, got %q", input, expected, actual)
+		}
+	}
+}
+
+func TestArgumentOffsets(t *testing.T) {
+	raw := `set “some value” x`
+	args := cmdlr2.ParseArguments(raw)
+
+	expected := []string{"set", "“some value”", "x"}
+	for i, span := range expected {
+		arg := args.Get(i)
+		if raw[arg.Start():arg.End()] != span {
+			t.Errorf("expected argument %d to span %q, got %q", i, span, raw[arg.Start():arg.End()])
+		}
+	}
+
+	args.Remove(0)
+	if args.Raw() != `“some value” x` || args.Get(0).Raw() != "some value" {
+		t.Errorf("expected the quotes to be kept after removing an argument, got %q", args.Raw())
+	}
+}
+
+func TestSubCommandArguments(t *testing.T) {
+	h := newHarness()
+	cmd := echoCommand("config")
+	cmd.SubCommands = []*cmdlr2.Command{echoCommand("set")}
+	h.Router.RegisterCMD(cmd)
+
+	assertReplies(t, "newlines", send(h, "!config set a\nb"), "set:a\nb")
+	assertReplies(t, "quotes", send(h, `!config set "a  b" c`), `set:"a  b" c`)
+}
@@ -0,0 +1,65 @@
+package cmdlr2
+
+import (
+	"github.com/andersfylling/disgord"
+)
+
+// messageWaiter receives the next message of a user in a channel. The message isn't handled as a command.
+type messageWaiter struct {
+	id        uint64
+	channelID disgord.Snowflake
+	userID    disgord.Snowflake
+	handler   func(message *disgord.Message)
+}
+
+// awaitMessage passes the next message of the given user in the given channel to the given handler instead of the
+// commands. The returned function stops waiting, it does nothing once the message arrived.
+func (r *Router) awaitMessage(channelID, userID disgord.Snowflake, handler func(message *disgord.Message)) func() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lastHandlerID++
+	id := r.lastHandlerID
+	r.messageWaiters = append(r.messageWaiters, &messageWaiter{
+		id:        id,
+		channelID: channelID,
+		userID:    userID,
+		handler:   handler,
+	})
+
+	return func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		r.removeMessageWaiter(id)
+	}
+}
+
+// takeMessageWaiter removes and returns the oldest waiter for the given message, nil if nobody waits for it
+func (r *Router) takeMessageWaiter(message *disgord.Message) *messageWaiter {
+	if message.Author == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, waiter := range r.messageWaiters {
+		if waiter.channelID == message.ChannelID && waiter.userID == message.Author.ID {
+			r.removeMessageWaiter(waiter.id)
+			return waiter
+		}
+	}
+	return nil
+}
+
+// removeMessageWaiter removes the waiter with the given ID, the lock has to be held
+func (r *Router) removeMessageWaiter(id uint64) {
+	waiters := make([]*messageWaiter, 0, len(r.messageWaiters))
+	for _, waiter := range r.messageWaiters {
+		if waiter.id != id {
+			waiters = append(waiters, waiter)
+		}
+	}
+	r.messageWaiters = waiters
+}
@@ -0,0 +1,186 @@
+// Package cmdlrtest provides utilities to drive a cmdlr2 router without a Discord connection.
+//
+//	h := cmdlrtest.New(cmdlr2.Create(&cmdlr2.Router{Prefixes: []string{"!"}}))
+//	h.Router.RegisterCMD(banCommand)
+//
+//	replies := h.Send("!ban @user 3d")
+package cmdlrtest
+
+import (
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+)
+
+// Harness wires a router to an in-memory transport and sends fake messages into it
+type Harness struct {
+	Router    *cmdlr2.Router
+	Transport *cmdlr2.MemoryTransport
+
+	// Bot is the user the router is running as
+	Bot *disgord.User
+	// User is the author of all messages sent using Send
+	User *disgord.User
+	// ChannelID is the channel all messages are sent into
+	ChannelID disgord.Snowflake
+	// GuildID is the guild all messages are sent into, zero means direct messages
+	GuildID disgord.Snowflake
+}
+
+// New creates a new harness for the given router and initializes the router with an in-memory transport
+func New(router *cmdlr2.Router) *Harness {
+	bot := &disgord.User{
+		ID:            1000,
+		Username:      "bot",
+		Discriminator: 1,
+		Bot:           true,
+	}
+	transport := cmdlr2.NewMemoryTransport(bot)
+	router.InitializeTransport(transport)
+
+	return &Harness{
+		Router:    router,
+		Transport: transport,
+		Bot:       bot,
+		User: &disgord.User{
+			ID:            2000,
+			Username:      "user",
+			Discriminator: 1,
+		},
+		ChannelID: 3000,
+		GuildID:   4000,
+	}
+}
+
+// Send sends a message with the given content as the default user and returns all messages sent in response
+func (h *Harness) Send(content string) []*disgord.Message {
+	return h.SendAs(h.User, content)
+}
+
+// SendAs sends a message with the given content as the given user and returns all messages sent in response
+func (h *Harness) SendAs(user *disgord.User, content string) []*disgord.Message {
+	message := &disgord.Message{
+		ID:        h.Transport.NextID(),
+		ChannelID: h.ChannelID,
+		GuildID:   h.GuildID,
+		Author:    user,
+		Content:   content,
+	}
+	return h.SendMessage(message)
+}
+
+// SendMessage sends the given message and returns all messages sent in response
+func (h *Harness) SendMessage(message *disgord.Message) []*disgord.Message {
+	return h.record(func() {
+		h.Transport.EmitMessage(&disgord.MessageCreate{Message: message})
+	})
+}
+
+// Interact invokes the slash command with the given name as the default user and returns all messages sent in response
+func (h *Harness) Interact(name string, options ...*cmdlr2.InteractionDataOption) []*disgord.Message {
+	return h.InteractAs(h.User, name, options...)
+}
+
+// InteractAs invokes the slash command with the given name as the given user and returns all messages sent in response
+func (h *Harness) InteractAs(user *disgord.User, name string, options ...*cmdlr2.InteractionDataOption) []*disgord.Message {
+	interaction := h.interaction(cmdlr2.InteractionApplicationCommand, user, name, options)
+	return h.record(func() {
+		h.Transport.EmitInteraction(interaction)
+	})
+}
+
+// Autocomplete requests the suggestions for the focused option of the slash command with the given name
+func (h *Harness) Autocomplete(name string, options ...*cmdlr2.InteractionDataOption) []*cmdlr2.ApplicationCommandOptionChoice {
+	interaction := h.interaction(cmdlr2.InteractionAutocomplete, h.User, name, options)
+	h.Transport.EmitInteraction(interaction)
+	return h.Transport.Suggestions(interaction.ID)
+}
+
+func (h *Harness) interaction(interactionType cmdlr2.InteractionType, user *disgord.User, name string, options []*cmdlr2.InteractionDataOption) *cmdlr2.Interaction {
+	interaction := &cmdlr2.Interaction{
+		ID:        h.Transport.NextID(),
+		Type:      interactionType,
+		GuildID:   h.GuildID,
+		ChannelID: h.ChannelID,
+		Data: &cmdlr2.InteractionData{
+			Name:    name,
+			Options: options,
+		},
+	}
+	if h.GuildID.IsZero() {
+		interaction.User = user
+	} else {
+		interaction.Member = &disgord.Member{GuildID: h.GuildID, UserID: user.ID, User: user}
+	}
+	return interaction
+}
+
+// Option creates an interaction option with the given name and value
+func Option(name string, value interface{}) *cmdlr2.InteractionDataOption {
+	return &cmdlr2.InteractionDataOption{Name: name, Value: value}
+}
+
+// Focused creates an interaction option which is currently autocompleted
+func Focused(name string, value interface{}) *cmdlr2.InteractionDataOption {
+	return &cmdlr2.InteractionDataOption{Name: name, Value: value, Focused: true}
+}
+
+// SubCommand creates an interaction option invoking the given sub command
+func SubCommand(name string, options ...*cmdlr2.InteractionDataOption) *cmdlr2.InteractionDataOption {
+	return &cmdlr2.InteractionDataOption{Name: name, Type: cmdlr2.OptionSubCommand, Options: options}
+}
+
+// SubCommandGroup creates an interaction option invoking the given sub command group
+func SubCommandGroup(name string, options ...*cmdlr2.InteractionDataOption) *cmdlr2.InteractionDataOption {
+	return &cmdlr2.InteractionDataOption{Name: name, Type: cmdlr2.OptionSubCommandGroup, Options: options}
+}
+
+// React adds a reaction of the default user to the given message
+func (h *Harness) React(messageID disgord.Snowflake, emoji string) {
+	h.ReactAs(h.User, messageID, emoji)
+}
+
+// ReactAs adds a reaction of the given user to the given message
+func (h *Harness) ReactAs(user *disgord.User, messageID disgord.Snowflake, emoji string) {
+	h.Transport.EmitReaction(&disgord.MessageReactionAdd{
+		UserID:       user.ID,
+		ChannelID:    h.ChannelID,
+		MessageID:    messageID,
+		PartialEmoji: &disgord.Emoji{Name: emoji},
+	})
+}
+
+// Click clicks the button with the given custom ID of the given message as the default user
+func (h *Harness) Click(messageID disgord.Snowflake, customID string) {
+	h.ClickAs(h.User, messageID, customID)
+}
+
+// ClickAs clicks the button with the given custom ID of the given message as the given user
+func (h *Harness) ClickAs(user *disgord.User, messageID disgord.Snowflake, customID string) {
+	interaction := h.interaction(cmdlr2.InteractionMessageComponent, user, "", nil)
+	interaction.Data.CustomID = customID
+	interaction.Message = h.Transport.Message(messageID)
+	h.Transport.EmitInteraction(interaction)
+}
+
+// Replies returns all messages the router sent which were not deleted
+func (h *Harness) Replies() []*disgord.Message {
+	return h.Transport.Messages()
+}
+
+// record executes the given function and returns all messages sent during its execution
+func (h *Harness) record(f func()) []*disgord.Message {
+	before := map[disgord.Snowflake]bool{}
+	for _, message := range h.Transport.Messages() {
+		before[message.ID] = true
+	}
+
+	f()
+
+	var replies []*disgord.Message
+	for _, message := range h.Transport.Messages() {
+		if !before[message.ID] {
+			replies = append(replies, message)
+		}
+	}
+	return replies
+}
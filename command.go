@@ -1,8 +1,11 @@
 package cmdlr2
 
 import (
-	"sort"
+	"context"
 	"strings"
+	"time"
+
+	"github.com/andersfylling/disgord"
 )
 
 type Command struct {
@@ -10,59 +13,136 @@ type Command struct {
 	Aliases     []string
 	Description string
 	Usage       string
+	Params      []*Param
 	Example     string
-	Flags       []string
+	Flags       []*Flag
 	IgnoreCase  bool
 	SubCommands []*Command
-	Handler     ExecutionHandler
+	Middlewares []Middleware
+	Cooldown    *Cooldown
+	// Timeout overrides the CommandTimeout of the router for this command and all of its sub commands
+	Timeout time.Duration
+
+	// Permissions are required by the invoking member, BotPermissions by the bot itself
+	Permissions    disgord.PermissionBit
+	BotPermissions disgord.PermissionBit
+	// AllowedRoles restricts the command to members having at least one of the given roles
+	AllowedRoles []disgord.Snowflake
+	OwnerOnly    bool
+	GuildOnly    bool
+	DMOnly       bool
+
+	Handler ExecutionHandler
+	// HandlerE is used instead of Handler if it is set, its errors are passed to the error handler of the router
+	HandlerE ErrorExecutionHandler
 }
 
-func (c *Command) GetSubCommand(name string) *Command {
-	sort.Slice(c.SubCommands, func(i, j int) bool {
-		return len(c.SubCommands[i].Name) > len(c.SubCommands[j].Name)
-	})
+// RegisterMiddleware registers a middleware which runs for this command and all of its sub commands
+func (c *Command) RegisterMiddleware(middleware Middleware) {
+	c.Middlewares = append(c.Middlewares, middleware)
+}
 
+// GetSubCommand returns the sub command with the given name or alias. Router.GetSubCmd should be preferred for
+// registered commands as it uses the prebuilt index of the router.
+func (c *Command) GetSubCommand(name string) *Command {
+	if name == "" {
+		return nil
+	}
 	for _, subCommand := range c.SubCommands {
-		toCheck := make([]string, len(subCommand.Aliases)+1)
-		toCheck = append(toCheck, subCommand.Name)
-		toCheck = append(toCheck, subCommand.Aliases...)
-		sort.Slice(toCheck, func(i, j int) bool {
-			return len(toCheck[i]) > len(toCheck[j])
-		})
-
-		if StringArrayContains(toCheck, name, subCommand.IgnoreCase) {
+		if Equals(subCommand.Name, name, subCommand.IgnoreCase) {
 			return subCommand
 		}
+		for _, alias := range subCommand.Aliases {
+			if Equals(alias, name, subCommand.IgnoreCase) {
+				return subCommand
+			}
+		}
 	}
 	return nil
 }
 
+// Trigger executes the command or one of its sub commands, wrapped into the router and command middlewares
 func (c *Command) Trigger(ctx *Ctx) {
+	c.trigger(ctx, nil)
+}
+
+func (c *Command) trigger(ctx *Ctx, parents []*Command) {
 	if len(ctx.Args.args) > 0 {
 		argument := ctx.Args.Get(0).Raw()
-		subCommand := c.GetSubCommand(argument)
+		var subCommand *Command
+		if ctx.Router != nil {
+			subCommand = ctx.Router.GetSubCmd(c, argument)
+		} else {
+			subCommand = c.GetSubCommand(argument)
+		}
 		if subCommand != nil {
-			args := ParseArguments("")
-			if ctx.Args.Amount() > 1 {
-				args = ParseArguments(strings.Join(strings.Split(ctx.Args.Raw(), " ")[1:], " "))
-			}
+			subCtx := *ctx
+			subCtx.Args = ctx.Args.from(1)
+			subCtx.Command = subCommand
+			// Copy the parents so sibling sub commands never share the same backing array
+			subCommand.trigger(&subCtx, append(parents[:len(parents):len(parents)], c))
+			return
+		}
+	}
+
+	handler := c.executionHandler()
+	if handler == nil {
+		return
+	}
 
-			subCommand.Trigger(&Ctx{
-				Session: ctx.Session,
-				Event:   ctx.Event,
-				Args:    args,
-				Client:  ctx.Client,
-				Router:  ctx.Router,
-				Command: subCommand,
-			})
+	ctx.path = append(parents[:len(parents):len(parents)], c)
+
+	defer ctx.recoverPanic()
+
+	if timeout := ctx.timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx.Context, cancel = context.WithTimeout(ctx.context(), timeout)
+		defer cancel()
+	}
+
+	if ctx.Router != nil {
+		if err := ctx.checkRequirements(); err != nil {
+			ctx.handleError(err)
 			return
 		}
 	}
 
-	// Prepare all middlewares
-	nextHandler := c.Handler
+	var chain []Middleware
+	if ctx.Router != nil {
+		chain = append(chain, ctx.Router.Middlewares...)
+	}
+	for _, command := range ctx.path {
+		chain = append(chain, command.Middlewares...)
+	}
+
+	if c.Cooldown != nil {
+		handler = c.withCooldown(handler)
+	}
+	if len(c.Flags) > 0 || len(c.Params) > 0 {
+		handler = c.withArguments(handler)
+	}
+
+	chainMiddlewares(handler, chain)(ctx)
+}
+
+// executionHandler returns the handler of the command, adapting HandlerE if it is set
+func (c *Command) executionHandler() ExecutionHandler {
+	if c.HandlerE == nil {
+		return c.Handler
+	}
+
+	return func(ctx *Ctx) {
+		if err := c.HandlerE(ctx); err != nil {
+			ctx.handleError(err)
+		}
+	}
+}
 
-	if nextHandler != nil {
-		nextHandler(ctx)
+// pathName returns the space separated names of the given command path like `config set`
+func pathName(path []*Command) string {
+	names := make([]string, len(path))
+	for index, command := range path {
+		names[index] = command.Name
 	}
+	return strings.Join(names, " ")
 }
@@ -0,0 +1,87 @@
+package cmdlr2
+
+import (
+	"context"
+
+	"github.com/andersfylling/disgord"
+)
+
+// ButtonStyle is the color of a button
+type ButtonStyle int
+
+const (
+	ButtonPrimary   ButtonStyle = 1
+	ButtonSecondary ButtonStyle = 2
+	ButtonSuccess   ButtonStyle = 3
+	ButtonDanger    ButtonStyle = 4
+)
+
+// Button is a clickable button below a message. Clicks are delivered as InteractionMessageComponent interactions
+// carrying the CustomID of the button.
+type Button struct {
+	CustomID string         `json:"custom_id"`
+	Label    string         `json:"label,omitempty"`
+	Emoji    *disgord.Emoji `json:"emoji,omitempty"`
+	Style    ButtonStyle    `json:"style"`
+	Disabled bool           `json:"disabled,omitempty"`
+}
+
+// ComponentResponder is implemented by interaction sources supporting message buttons. Transports implementing it
+// also apply MessageEdit.Buttons when editing messages.
+type ComponentResponder interface {
+	// SendMessageWithButtons sends a new message with the given buttons into the given channel
+	SendMessageWithButtons(ctx context.Context, channelID disgord.Snowflake, params *disgord.CreateMessageParams, buttons []*Button) (*disgord.Message, error)
+
+	// UpdateComponentMessage answers a click by applying the given changes to the message of the clicked button. A nil
+	// edit only acknowledges the click.
+	UpdateComponentMessage(ctx context.Context, interaction *Interaction, edit *MessageEdit) error
+}
+
+type componentHandler struct {
+	id      uint64
+	handler func(interaction *Interaction)
+}
+
+// RegisterComponentHandler registers a handler which gets called for every clicked button. The returned function
+// unregisters the handler again.
+func (r *Router) RegisterComponentHandler(handler func(interaction *Interaction)) func() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lastHandlerID++
+	id := r.lastHandlerID
+	r.componentHandlers = append(r.componentHandlers, &componentHandler{id: id, handler: handler})
+
+	return func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		// Copy the handlers as handleComponent iterates over them without holding the lock
+		handlers := make([]*componentHandler, 0, len(r.componentHandlers))
+		for _, h := range r.componentHandlers {
+			if h.id != id {
+				handlers = append(handlers, h)
+			}
+		}
+		r.componentHandlers = handlers
+	}
+}
+
+// handleComponent passes the given click to all registered component handlers
+func (r *Router) handleComponent(interaction *Interaction) {
+	if interaction.Data == nil || interaction.Message == nil || !r.begin() {
+		return
+	}
+	defer r.running.Done()
+
+	r.mutex.RLock()
+	handlers := r.componentHandlers
+	r.mutex.RUnlock()
+
+	message := interaction.message("")
+	for _, h := range handlers {
+		r.runHandler(message, func() {
+			h.handler(interaction)
+		})
+	}
+}
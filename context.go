@@ -2,38 +2,79 @@ package cmdlr2
 
 import (
 	"context"
+	"time"
+
 	"github.com/andersfylling/disgord"
 )
 
 type Ctx struct {
-	Client  *disgord.Client
-	Session *disgord.Session
-	Event   *disgord.MessageCreate
-	Args    *Arguments
+	// Context is derived from the lifetime of the router and limited by the command timeout. It is cancelled once the
+	// router shuts down and should be passed to all calls made by the handler.
+	Context context.Context
+	// Client is only set if the router runs on a disgord client
+	Client *disgord.Client
+	// Deprecated: Session is only set if the router runs on a disgord client, use Responder instead
+	Session   *disgord.Session
+	Responder Responder
+	Event     *disgord.MessageCreate
+	// Prefix is the prefix the command was invoked with, `/` for slash commands
+	Prefix string
+	// Interaction is set if the command was invoked as a slash command
+	Interaction *Interaction
+	Args        *Arguments
+	// Params holds the parsed values of the parameters declared by the command
+	Params ParamValues
+	// Flags holds the parsed values of the flags declared by the command
+	Flags   ParamValues
 	Command *Command
 	Router  *Router
+
+	// path holds the resolved command and all of its parents, outermost first
+	path []*Command
+	// options holds the options of the invoked slash command
+	options []*InteractionDataOption
 }
 
 type ExecutionHandler func(ctx *Ctx)
 
-func (ctx *Ctx) ResponseText(text string) error {
-	channel, err := ctx.Client.Channel(ctx.Event.Message.ChannelID).Get()
-	if err != nil {
-		return err
+// context returns the context of the execution, falling back to the background context for manually created contexts
+func (ctx *Ctx) context() context.Context {
+	if ctx.Context == nil {
+		return context.Background()
+	}
+	return ctx.Context
+}
+
+// timeout returns the timeout of the innermost command of the path which declares one or the default timeout of the
+// router
+func (ctx *Ctx) timeout() time.Duration {
+	for index := len(ctx.path) - 1; index >= 0; index-- {
+		if ctx.path[index].Timeout > 0 {
+			return ctx.path[index].Timeout
+		}
 	}
-	_, err = channel.SendMsgString(context.Background(), *ctx.Session, text)
+	if ctx.Router != nil {
+		return ctx.Router.CommandTimeout
+	}
+	return 0
+}
+
+func (ctx *Ctx) ResponseText(text string) error {
+	_, err := ctx.Responder.SendMessage(ctx.context(), ctx.Event.Message.ChannelID, &disgord.CreateMessageParams{
+		Content: text,
+	})
 	return err
 }
 
 func (ctx *Ctx) ResponseEmbed(embed *disgord.Embed) error {
-	_, err := ctx.Client.Channel(ctx.Event.Message.ChannelID).CreateMessage(&disgord.CreateMessageParams{
+	_, err := ctx.Responder.SendMessage(ctx.context(), ctx.Event.Message.ChannelID, &disgord.CreateMessageParams{
 		Embed: embed,
 	})
 	return err
 }
 
 func (ctx *Ctx) ResponseTextEmbed(text string, embed *disgord.Embed) error {
-	_, err := ctx.Client.Channel(ctx.Event.Message.ChannelID).CreateMessage(&disgord.CreateMessageParams{
+	_, err := ctx.Responder.SendMessage(ctx.context(), ctx.Event.Message.ChannelID, &disgord.CreateMessageParams{
 		Embed:   embed,
 		Content: text,
 	})
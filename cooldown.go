@@ -0,0 +1,189 @@
+package cmdlr2
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// CooldownScope defines who shares a cooldown
+type CooldownScope int
+
+const (
+	// CooldownUser gives every user their own cooldown
+	CooldownUser CooldownScope = iota
+	// CooldownChannel shares the cooldown between everyone in a channel
+	CooldownChannel
+	// CooldownGuild shares the cooldown between everyone in a guild. Direct messages fall back to the channel.
+	CooldownGuild
+	// CooldownGlobal shares the cooldown between everyone
+	CooldownGlobal
+)
+
+// CooldownMode defines how uses of a command are counted
+type CooldownMode int
+
+const (
+	// CooldownFixedWindow allows Limit uses per Window, the window starts with the first use
+	CooldownFixedWindow CooldownMode = iota
+	// CooldownTokenBucket allows bursts of Limit uses and refills one use every Window / Limit
+	CooldownTokenBucket
+)
+
+// Cooldown limits how often a command may be used
+type Cooldown struct {
+	Scope  CooldownScope
+	Mode   CooldownMode
+	Limit  int
+	Window time.Duration
+}
+
+// CooldownError is passed to the error handler if a command is used too often
+type CooldownError struct {
+	Cooldown   *Cooldown
+	RetryAfter time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("command is on cooldown, try again in %s", e.RetryAfter)
+}
+
+// CooldownStore keeps track of the cooldown state. The default is an in-memory store, shards sharing their cooldowns
+// need a store backed by a shared database.
+type CooldownStore interface {
+	// Take uses the given cooldown once for the given key. It returns how long the caller has to wait if the cooldown
+	// is exhausted and zero otherwise.
+	Take(key string, cooldown *Cooldown) (retryAfter time.Duration, err error)
+}
+
+type cooldownEntry struct {
+	// count is the number of uses in the current window or the amount of tokens left in the bucket
+	count   float64
+	start   time.Time
+	expires time.Time
+}
+
+// MemoryCooldownStore is the in-memory CooldownStore used by default
+type MemoryCooldownStore struct {
+	mutex     sync.Mutex
+	entries   map[string]*cooldownEntry
+	lastSweep time.Time
+}
+
+// NewMemoryCooldownStore creates a new empty in-memory cooldown store
+func NewMemoryCooldownStore() *MemoryCooldownStore {
+	return &MemoryCooldownStore{
+		entries:   map[string]*cooldownEntry{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryCooldownStore) Take(key string, cooldown *Cooldown) (time.Duration, error) {
+	if cooldown.Window <= 0 {
+		return 0, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	limit := float64(cooldown.Limit)
+	if limit < 1 {
+		limit = 1
+	}
+
+	entry, ok := s.entries[key]
+
+	switch cooldown.Mode {
+	case CooldownTokenBucket:
+		rate := limit / cooldown.Window.Seconds()
+		if !ok {
+			entry = &cooldownEntry{count: limit, start: now}
+			s.entries[key] = entry
+		}
+
+		entry.count = math.Min(limit, entry.count+now.Sub(entry.start).Seconds()*rate)
+		entry.start = now
+		if entry.count < 1 {
+			return time.Duration((1 - entry.count) / rate * float64(time.Second)), nil
+		}
+		entry.count--
+		entry.expires = now.Add(time.Duration((limit - entry.count) / rate * float64(time.Second)))
+	default:
+		if !ok || !now.Before(entry.expires) {
+			entry = &cooldownEntry{start: now, expires: now.Add(cooldown.Window)}
+			s.entries[key] = entry
+		}
+
+		if entry.count >= limit {
+			return entry.expires.Sub(now), nil
+		}
+		entry.count++
+	}
+
+	return 0, nil
+}
+
+// sweep removes all expired entries at most once a minute
+func (s *MemoryCooldownStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// cooldownKey builds the key identifying the cooldown of the current invocation
+func (ctx *Ctx) cooldownKey(cooldown *Cooldown) string {
+	msg := ctx.Event.Message
+
+	var scope string
+	switch cooldown.Scope {
+	case CooldownUser:
+		scope = "u" + msg.Author.ID.String()
+	case CooldownChannel:
+		scope = "c" + msg.ChannelID.String()
+	case CooldownGuild:
+		if msg.GuildID.IsZero() {
+			scope = "c" + msg.ChannelID.String()
+		} else {
+			scope = "g" + msg.GuildID.String()
+		}
+	case CooldownGlobal:
+		scope = "*"
+	}
+
+	return pathName(ctx.path) + ":" + scope
+}
+
+// withCooldown wraps the given handler so that it only gets called if the cooldown of the command isn't exhausted.
+// If the store fails, the command is executed anyways.
+func (c *Command) withCooldown(handler ExecutionHandler) ExecutionHandler {
+	return func(ctx *Ctx) {
+		if ctx.Router == nil || ctx.Router.CooldownStore == nil {
+			handler(ctx)
+			return
+		}
+
+		retryAfter, err := ctx.Router.CooldownStore.Take(ctx.cooldownKey(c.Cooldown), c.Cooldown)
+		if err == nil && retryAfter > 0 {
+			ctx.handleError(&CooldownError{Cooldown: c.Cooldown, RetryAfter: retryAfter})
+			return
+		}
+
+		handler(ctx)
+	}
+}
+
+func cooldownErrorText(err *CooldownError) string {
+	seconds := time.Duration(math.Ceil(err.RetryAfter.Seconds())) * time.Second
+	return fmt.Sprintf("This command is on cooldown, try again in %s.", seconds)
+}
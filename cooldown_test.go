@@ -0,0 +1,81 @@
+package cmdlr2_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+)
+
+func take(t *testing.T, store cmdlr2.CooldownStore, key string, cooldown *cmdlr2.Cooldown) time.Duration {
+	t.Helper()
+	retryAfter, err := store.Take(key, cooldown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return retryAfter
+}
+
+func TestFixedWindowCooldown(t *testing.T) {
+	store := cmdlr2.NewMemoryCooldownStore()
+	cooldown := &cmdlr2.Cooldown{Mode: cmdlr2.CooldownFixedWindow, Limit: 2, Window: 50 * time.Millisecond}
+
+	if take(t, store, "a", cooldown) != 0 || take(t, store, "a", cooldown) != 0 {
+		t.Fatal("expected the first two uses to be allowed")
+	}
+	if retryAfter := take(t, store, "a", cooldown); retryAfter <= 0 || retryAfter > cooldown.Window {
+		t.Errorf("expected the third use to wait for the window, got %v", retryAfter)
+	}
+	if take(t, store, "b", cooldown) != 0 {
+		t.Error("expected other keys to have their own window")
+	}
+
+	time.Sleep(cooldown.Window)
+	if take(t, store, "a", cooldown) != 0 {
+		t.Error("expected the window to be reset")
+	}
+}
+
+func TestTokenBucketCooldown(t *testing.T) {
+	store := cmdlr2.NewMemoryCooldownStore()
+	cooldown := &cmdlr2.Cooldown{Mode: cmdlr2.CooldownTokenBucket, Limit: 2, Window: 100 * time.Millisecond}
+
+	if take(t, store, "a", cooldown) != 0 || take(t, store, "a", cooldown) != 0 {
+		t.Fatal("expected a burst of two uses to be allowed")
+	}
+	if retryAfter := take(t, store, "a", cooldown); retryAfter <= 0 || retryAfter > cooldown.Window/2 {
+		t.Errorf("expected to wait for a single token, got %v", retryAfter)
+	}
+
+	// A token is refilled every Window / Limit
+	time.Sleep(cooldown.Window / 2)
+	if take(t, store, "a", cooldown) != 0 {
+		t.Error("expected a token to be refilled")
+	}
+	if take(t, store, "a", cooldown) == 0 {
+		t.Error("expected only a single token to be refilled")
+	}
+}
+
+func TestCooldownScopes(t *testing.T) {
+	h := newHarness()
+	other := &disgord.User{ID: 1}
+
+	user := echoCommand("user")
+	user.Cooldown = &cmdlr2.Cooldown{Scope: cmdlr2.CooldownUser, Limit: 1, Window: time.Minute}
+	guild := echoCommand("guild")
+	guild.Cooldown = &cmdlr2.Cooldown{Scope: cmdlr2.CooldownGuild, Limit: 1, Window: time.Minute}
+	h.Router.RegisterCMDList([]*cmdlr2.Command{user, guild})
+
+	assertReplies(t, "!user", send(h, "!user"), "user:")
+	assertReplies(t, "!user", send(h, "!user"), "This command is on cooldown, try again in 1m0s.")
+	if replies := h.SendAs(other, "!user"); len(replies) != 1 || replies[0].Content != "user:" {
+		t.Errorf("expected other users to have their own cooldown, got %v", replies)
+	}
+
+	assertReplies(t, "!guild", send(h, "!guild"), "guild:")
+	if replies := h.SendAs(other, "!guild"); len(replies) != 1 || replies[0].Content != "This command is on cooldown, try again in 1m0s." {
+		t.Errorf("expected the guild to share the cooldown, got %v", replies)
+	}
+}
@@ -0,0 +1,145 @@
+package cmdlr2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/andersfylling/disgord"
+)
+
+// ErrorExecutionHandler is a command handler which returns an error instead of handling it itself
+type ErrorExecutionHandler func(ctx *Ctx) error
+
+// UserError is an error whose message is shown to the user as is by the DefaultErrorHandler
+type UserError struct {
+	Message string
+}
+
+func (e *UserError) Error() string {
+	return e.Message
+}
+
+// NewUserError creates a new UserError with the given formatted message
+func NewUserError(format string, a ...interface{}) error {
+	return &UserError{Message: fmt.Sprintf(format, a...)}
+}
+
+// PanicError is passed to the error handler if a command handler, middleware, reaction or component handler panicked
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("command panicked: %v", e.Value)
+}
+
+// DefaultErrorHandler answers argument, permission, cooldown, lookup, queue, prompt and wizard errors with an
+// explanation, UserErrors with their message and all other errors with a generic message. The other errors are logged,
+// including the stack of panics.
+func DefaultErrorHandler(ctx *Ctx, err error) {
+	var argumentError *ArgumentError
+	var permissionError *PermissionError
+	var cooldownError *CooldownError
+	var userError *UserError
+	var notFoundError *EntityNotFoundError
+
+	var text string
+	switch {
+	case errors.As(err, &argumentError):
+		text = argumentErrorText(ctx, argumentError)
+	case errors.As(err, &permissionError):
+		text = permissionErrorText(permissionError)
+	case errors.As(err, &cooldownError):
+		text = cooldownErrorText(cooldownError)
+	case errors.As(err, &notFoundError):
+		text = fmt.Sprintf("Couldn't find any %s matching `%s`.", notFoundError.Kind, notFoundError.Query)
+	case errors.As(err, &userError):
+		text = userError.Message
+	case errors.Is(err, ErrQueueFull):
+		text = "I'm busy right now, please try again in a moment."
+	case errors.Is(err, ErrShuttingDown):
+		text = "I'm shutting down, please try again later."
+	case errors.Is(err, ErrPromptTimeout):
+		text = "You didn't answer in time."
+	case errors.Is(err, ErrWizardCancelled):
+		text = "Cancelled."
+	case errors.Is(err, ErrTooManyRetries):
+		text = "Too many invalid answers, please start again."
+	default:
+		logError(ctx, err)
+		text = "Something went wrong while executing this command."
+	}
+	_ = ctx.ResponseText(text)
+}
+
+// logError writes the given unexpected error to the standard logger
+func logError(ctx *Ctx, err error) {
+	prefix := "cmdlr2"
+	if ctx.Command != nil {
+		prefix += fmt.Sprintf(": command `%s`", ctx.Command.Name)
+	}
+
+	var panicError *PanicError
+	if errors.As(err, &panicError) {
+		log.Printf("%s: %v\n%s", prefix, err, panicError.Stack)
+		return
+	}
+	log.Printf("%s: %v", prefix, err)
+}
+
+// HandleError passes the given error to the error handler of the router. Middlewares can use it to report errors the
+// same way command handlers do. If the execution timed out, the error handler gets a context bound to the lifetime of
+// the router instead, so it can still answer.
+func (r *Router) HandleError(ctx *Ctx, err error) {
+	if ctx.context().Err() != nil {
+		// The execution timed out or was cancelled, the error is still reported while the router is running
+		reportCtx := *ctx
+		reportCtx.Context = &detachedContext{Context: r.lifetimeContext(), values: ctx.context()}
+		ctx = &reportCtx
+	}
+
+	if r.ErrorHandler != nil {
+		r.ErrorHandler(ctx, err)
+		return
+	}
+	DefaultErrorHandler(ctx, err)
+}
+
+// detachedContext is bound to the lifetime of the router but keeps the values of the execution context
+type detachedContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c *detachedContext) Value(key interface{}) interface{} {
+	return c.values.Value(key)
+}
+
+func (ctx *Ctx) handleError(err error) {
+	if ctx.Router != nil {
+		ctx.Router.HandleError(ctx, err)
+	}
+}
+
+// recoverPanic passes a panic of the current goroutine to the error handler. It has to be deferred.
+func (ctx *Ctx) recoverPanic() {
+	if value := recover(); value != nil {
+		ctx.handleError(&PanicError{Value: value, Stack: debug.Stack()})
+	}
+}
+
+// runHandler runs the given reaction, component or message handler. A panic is passed to the error handler which
+// answers in the channel of the given message.
+func (r *Router) runHandler(message *disgord.Message, handler func()) {
+	defer func() {
+		if value := recover(); value != nil {
+			ctx := r.newCtx(&disgord.MessageCreate{Message: message}, ParseArguments(""), nil)
+			ctx.handleError(&PanicError{Value: value, Stack: debug.Stack()})
+		}
+	}()
+	handler()
+}
@@ -0,0 +1,169 @@
+package cmdlr2
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Flag declares a named option of a command which may be given anywhere before the positional arguments, like
+// `--force`, `-f`, `--limit=10`, `-l10`, `--reason "spam"` or combined short flags like `-fv`
+type Flag struct {
+	// Name is the long name of the flag used as `--name`
+	Name string
+	// Short is an optional single character used as `-s`
+	Short string
+	// Type defaults to ParamBool. Boolean flags don't take a value unless it's given using `--name=value` or
+	// `-n=value`.
+	Type        ParamType
+	Description string
+	// Default is used if the flag isn't given
+	Default interface{}
+}
+
+func (f *Flag) paramType() ParamType {
+	if f.Type == "" {
+		return ParamBool
+	}
+	return f.Type
+}
+
+// usage renders the flag like `-f, --force` or `--limit <number>` using the given display name of its type
+func (f *Flag) usage(display string) string {
+	usage := "--" + f.Name
+	if f.Short != "" {
+		usage = "-" + f.Short + ", " + usage
+	}
+	if f.paramType() != ParamBool {
+		usage += " <" + display + ">"
+	}
+	return usage
+}
+
+// ParseFlags extracts the given flags from the arguments. It returns the flag values and the remaining positional
+// arguments. Parsing stops at the first positional argument or at `--`. Only the built-in parameter types are
+// supported as the custom types are registered on the router.
+func ParseFlags(flags []*Flag, args *Arguments) (ParamValues, *Arguments, error) {
+	return parseFlags(nil, flags, args)
+}
+
+func parseFlags(ctx *Ctx, flags []*Flag, args *Arguments) (ParamValues, *Arguments, error) {
+	values := ParamValues{}
+	for _, flag := range flags {
+		if flag.Default != nil {
+			values[flag.Name] = flag.Default
+		} else if flag.paramType() == ParamBool {
+			values[flag.Name] = false
+		}
+	}
+
+	consumed := 0
+	for consumed < args.Amount() {
+		raw := args.Get(consumed).Raw()
+		if raw == "--" {
+			consumed++
+			break
+		}
+		if !isFlagToken(raw) {
+			break
+		}
+		consumed++
+
+		if strings.HasPrefix(raw, "--") {
+			name, value, hasValue := raw[2:], "", false
+			if i := strings.Index(name, "="); i >= 0 {
+				name, value, hasValue = name[:i], name[i+1:], true
+			}
+			flag := findFlag(flags, name, true)
+			if flag == nil {
+				return nil, nil, unknownFlagError(raw)
+			}
+
+			if !hasValue && flag.paramType() == ParamBool {
+				values[flag.Name] = true
+				continue
+			}
+			if !hasValue {
+				if consumed >= args.Amount() {
+					return nil, nil, &ArgumentError{Flag: flag, Raw: raw, Message: "needs a value"}
+				}
+				value = args.Get(consumed).Raw()
+				consumed++
+			}
+			if err := parseFlag(ctx, values, flag, value); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		// Every character of a short flag group is its own flag until the first one taking a value. It takes the rest
+		// of the group like `-l10` or `-l=10` or, if the group ends with it, the next argument.
+		group := []rune(raw[1:])
+		for index, r := range group {
+			flag := findFlag(flags, string(r), false)
+			if flag == nil {
+				return nil, nil, unknownFlagError(raw)
+			}
+
+			rest := string(group[index+1:])
+			if flag.paramType() == ParamBool && !strings.HasPrefix(rest, "=") {
+				values[flag.Name] = true
+				continue
+			}
+
+			value := strings.TrimPrefix(rest, "=")
+			if rest == "" {
+				if consumed >= args.Amount() {
+					return nil, nil, &ArgumentError{Flag: flag, Raw: raw, Message: "needs a value"}
+				}
+				value = args.Get(consumed).Raw()
+				consumed++
+			}
+			if err := parseFlag(ctx, values, flag, value); err != nil {
+				return nil, nil, err
+			}
+			break
+		}
+	}
+
+	return values, args.from(consumed), nil
+}
+
+// parseFlag converts the given raw value of the flag and stores it in the given values
+func parseFlag(ctx *Ctx, values ParamValues, flag *Flag, raw string) error {
+	converter, ok := ctx.paramConverter(flag.paramType())
+	if !ok {
+		return fmt.Errorf("unknown flag type %q", flag.Type)
+	}
+	value, err := converter.convert(ctx, raw)
+	if err != nil {
+		err.Flag = flag
+		return err
+	}
+	values[flag.Name] = value
+	return nil
+}
+
+func unknownFlagError(raw string) error {
+	return &ArgumentError{Raw: raw, Message: fmt.Sprintf("unknown flag `%s`", raw)}
+}
+
+func findFlag(flags []*Flag, name string, long bool) *Flag {
+	for _, flag := range flags {
+		if (long && flag.Name == name) || (!long && flag.Short == name) {
+			return flag
+		}
+	}
+	return nil
+}
+
+// isFlagToken checks whether the given argument looks like a flag. Negative numbers are not treated as flags.
+func isFlagToken(raw string) bool {
+	if len(raw) < 2 || raw[0] != '-' {
+		return false
+	}
+	if raw[1] == '-' {
+		return len(raw) > 2
+	}
+	return !unicode.IsDigit(rune(raw[1]))
+}
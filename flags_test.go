@@ -0,0 +1,89 @@
+package cmdlr2_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zackartz/cmdlr2"
+)
+
+var purgeFlags = []*cmdlr2.Flag{
+	{Name: "force", Short: "f"},
+	{Name: "verbose", Short: "v"},
+	{Name: "limit", Short: "l", Type: cmdlr2.ParamInt, Default: 100},
+	{Name: "reason", Type: cmdlr2.ParamString},
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := map[string]string{
+		"":                          "false false 100  []",
+		"--force":                   "true false 100  []",
+		"-f a b":                    "true false 100  [a b]",
+		"-fv":                       "true true 100  []",
+		"--limit=10":                "false false 10  []",
+		"--limit 10 x":              "false false 10  [x]",
+		"-l 10":                     "false false 10  []",
+		"-l10":                      "false false 10  []",
+		"-l=10":                     "false false 10  []",
+		"-fl10":                     "true false 10  []",
+		"-vfl 5":                    "true true 5  []",
+		"-f=false -v":               "false true 100  []",
+		"--force=false":             "false false 100  []",
+		`--reason "spam bot" -f x`:  "true false 100 spam bot [x]",
+		"-f -- --verbose":           "true false 100  [--verbose]",
+		"-5":                        "false false 100  [-5]",
+		"x --force":                 "false false 100  [x --force]",
+		"--limit=20 -l30 --limit 7": "false false 7  []",
+	}
+	for input, expected := range tests {
+		values, args, err := cmdlr2.ParseFlags(purgeFlags, cmdlr2.ParseArguments(input))
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", input, err)
+			continue
+		}
+
+		var rest []string
+		for index := 0; index < args.Amount(); index++ {
+			rest = append(rest, args.Get(index).Raw())
+		}
+		actual := fmt.Sprintf("%v %v %d %s %v", values.Bool("force"), values.Bool("verbose"), values.Int("limit"), values.String("reason"), rest)
+		if actual != expected {
+			t.Errorf("%q: expected %q, got %q", input, expected, actual)
+		}
+	}
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	tests := map[string]string{
+		"--all":      "unknown flag `--all`",
+		"-fx":        "unknown flag `-fx`",
+		"--limit":    "invalid flag `--limit`: needs a value",
+		"-fl":        "invalid flag `--limit`: needs a value",
+		"-lten":      "invalid flag `--limit`: must be a number",
+		"-l=":        "invalid flag `--limit`: must be a number",
+		"--limit=x":  "invalid flag `--limit`: must be a number",
+		"--force=no": "invalid flag `--force`: must be a boolean",
+	}
+	for input, expected := range tests {
+		if _, _, err := cmdlr2.ParseFlags(purgeFlags, cmdlr2.ParseArguments(input)); err == nil || err.Error() != expected {
+			t.Errorf("%q: expected %q, got %v", input, expected, err)
+		}
+	}
+}
+
+func TestFlagsCommand(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name:   "purge",
+		Flags:  purgeFlags,
+		Params: []*cmdlr2.Param{{Name: "channel", Type: cmdlr2.ParamString, Optional: true}},
+		Handler: func(ctx *cmdlr2.Ctx) {
+			_ = ctx.ResponseText(fmt.Sprintf("%s %d %v", ctx.Params.String("channel"), ctx.Flags.Int("limit"), ctx.Flags.Bool("force")))
+		},
+	})
+
+	assertReplies(t, "!purge -fl5 general", send(h, "!purge -fl5 general"), "general 5 true")
+	assertReplies(t, "!purge general", send(h, "!purge general"), "general 100 false")
+	assertReplies(t, "!purge -x", send(h, "!purge -x"),
+		"Invalid usage: unknown flag `-x`.\nUsage: `!purge [flags] [channel]`")
+}
@@ -1,13 +1,18 @@
 module github.com/zackartz/cmdlr2
 
-go 1.16
+go 1.18
 
 require (
-	github.com/andersfylling/disgord v0.24.2 // indirect
-	github.com/karrick/tparse/v2 v2.8.2 // indirect
+	github.com/andersfylling/disgord v0.24.2
+	github.com/karrick/tparse/v2 v2.8.2
+)
+
+require (
+	github.com/andersfylling/snowflake/v4 v4.0.2 // indirect
 	github.com/klauspost/compress v1.11.6 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
 	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad // indirect
 	golang.org/x/net v0.0.0-20201224014010-6772e930b67b // indirect
 	golang.org/x/sys v0.0.0-20210110051926-789bb1bd4061 // indirect
+	nhooyr.io/websocket v1.8.6 // indirect
 )
@@ -2,65 +2,96 @@ package cmdlr2
 
 import (
 	"fmt"
-	"math"
-	"strconv"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/andersfylling/disgord"
 )
 
-func (r *Router) RegisterDefaultHelpCommand(c *disgord.Client) {
-	r.InitializeStorage("hdl_helpMessages")
-
-	c.Gateway().MessageReactionAdd(func(s disgord.Session, h *disgord.MessageReactionAdd) {
-		channelID := h.ChannelID
-		messageID := h.MessageID
-		userID := h.UserID
-		u, _ := c.Cache().GetCurrentUser()
+// HelpRenderer renders the embeds of the default help command
+type HelpRenderer interface {
+	// CommandList renders the pages of the list of all commands
+	CommandList(ctx *Ctx, commands []*Command) []*disgord.Embed
+	// CommandHelp renders the information about the given command. Command is nil if the requested command doesn't
+	// exist.
+	CommandHelp(ctx *Ctx, command *Command) *disgord.Embed
+}
 
-		if userID == u.ID {
-			return
-		}
+// HelpStrings are the texts used by the DefaultHelpRenderer. The texts containing verbs are format strings.
+type HelpStrings struct {
+	// ListTitle receives the current page and the amount of pages
+	ListTitle string
+	// ListDescription receives the prefix
+	ListDescription string
+	CommandTitle    string
+	// CommandDescription receives the name of the command
+	CommandDescription string
+	ErrorTitle         string
+	ErrorField         string
+	// UnknownCommand receives the prefix
+	UnknownCommand string
+	Name           string
+	SubCommands    string
+	NoSubCommands  string
+	Aliases        string
+	NoAliases      string
+	Description    string
+	Arguments      string
+	Flags          string
+	Usage          string
+	Example        string
+}
 
-		rawPage, ok := r.Storage["hdl_helpMessages"].Get(fmt.Sprintf("%v:%v:%v", channelID, messageID, userID))
-		if !ok {
-			return
-		}
+// EnglishHelpStrings are the texts used if there is no translation for a locale
+var EnglishHelpStrings = &HelpStrings{
+	ListTitle:          "Command List (Page %d / %d)",
+	ListDescription:    "These are all the available commands. Type `%shelp <command_name>` to find out more about a specific command.",
+	CommandTitle:       "Command Information",
+	CommandDescription: "Displaying the information for the `%s` command.",
+	ErrorTitle:         "Error",
+	ErrorField:         "Message",
+	UnknownCommand:     "The given command doesn't exist. Type `%shelp` for a list of available commands.",
+	Name:               "Name",
+	SubCommands:        "Sub Commands",
+	NoSubCommands:      "No sub commands",
+	Aliases:            "Aliases",
+	NoAliases:          "No aliases",
+	Description:        "Description",
+	Arguments:          "Arguments",
+	Flags:              "Flags",
+	Usage:              "Usage",
+	Example:            "Example",
+}
 
-		page := rawPage.(int)
-		if page <= 0 {
-			return
-		}
+// DefaultHelpRenderer renders the help as embeds listing a few commands per page
+type DefaultHelpRenderer struct {
+	Color      int
+	ErrorColor int
+	// CommandsPerPage is the amount of commands listed per page
+	CommandsPerPage int
+	// InlineFields renders the fields of the embeds next to each other
+	InlineFields bool
+	// Translations holds the texts by the locales returned by Router.Locale, EnglishHelpStrings is used for all other
+	// locales
+	Translations map[string]*HelpStrings
+}
 
-		reactionName := h.PartialEmoji.Name
-		switch reactionName {
-		case "⬅":
-			embed, newPage := renderDefaultGeneralHelpEmbed(r, page-1)
-			page = newPage
-			b := c.Channel(channelID).Message(messageID)
-			b.SetEmbed(embed)
-
-			q := c.Channel(channelID).Message(messageID).Reaction(reactionName)
-			_ = q.DeleteUser(userID)
-			break
-		case "❌":
-			_ = c.Channel(channelID).Message(messageID).Delete()
-			break
-		case "➡":
-			embed, newPage := renderDefaultGeneralHelpEmbed(r, page+1)
-			page = newPage
-			b := c.Channel(channelID).Message(messageID)
-			b.SetEmbed(embed)
-
-			q := c.Channel(channelID).Message(messageID).Reaction(reactionName)
-			_ = q.DeleteUser(userID)
-		}
+var _ HelpRenderer = (*DefaultHelpRenderer)(nil)
 
-		r.Storage["hdl_helpMessages"].Set(fmt.Sprintf("%v:%v:%v", channelID, messageID, userID), page)
-	})
+// NewDefaultHelpRenderer creates a new DefaultHelpRenderer using yellow embeds listing 5 commands per page
+func NewDefaultHelpRenderer() *DefaultHelpRenderer {
+	return &DefaultHelpRenderer{
+		Color:           0xffff00,
+		ErrorColor:      0xff0000,
+		CommandsPerPage: 5,
+		Translations:    map[string]*HelpStrings{},
+	}
+}
 
-	r.RegisterCMD(&Command{
+// RegisterDefaultHelpCommand registers the default help command. It fails if there already is a command named `help`.
+func (r *Router) RegisterDefaultHelpCommand() error {
+	return r.RegisterCMD(&Command{
 		Name:        "help",
 		Description: "Lists all the available commands or displays some information about a specific command",
 		Usage:       "help [command name]",
@@ -70,65 +101,109 @@ func (r *Router) RegisterDefaultHelpCommand(c *disgord.Client) {
 	})
 }
 
+// helpRenderer returns the configured help renderer or a DefaultHelpRenderer
+func (r *Router) helpRenderer() HelpRenderer {
+	if r.HelpRenderer != nil {
+		return r.HelpRenderer
+	}
+	return NewDefaultHelpRenderer()
+}
+
+// locale returns the locale of the given context, an empty string if Router.Locale isn't set
+func (ctx *Ctx) locale() string {
+	if ctx.Router == nil || ctx.Router.Locale == nil {
+		return ""
+	}
+	return ctx.Router.Locale(ctx)
+}
+
+// helpPrefix returns the prefix the help command was invoked with, the first prefix of the router if there is none.
+// Mentions of the bot are shown like `@bot `.
+func helpPrefix(ctx *Ctx) string {
+	if strings.HasPrefix(ctx.Prefix, "<@") && ctx.Router != nil {
+		if bot, err := ctx.Router.CurrentUser(); err == nil {
+			return "@" + bot.Username + " "
+		}
+	} else if ctx.Prefix != "" {
+		return ctx.Prefix
+	}
+	if ctx.Router != nil && len(ctx.Router.Prefixes) > 0 {
+		return ctx.Router.Prefixes[0]
+	}
+	return ""
+}
+
 func generalHelpCommand(ctx *Ctx) {
 	if ctx.Args.Amount() > 0 {
 		specificHelpCommand(ctx)
 		return
 	}
 
-	channelID := ctx.Event.Message.ChannelID
-	c := ctx.Client
-
-	embed, _ := renderDefaultGeneralHelpEmbed(ctx.Router, 1)
-	message, _ := ctx.Client.Channel(channelID).CreateMessage(&disgord.CreateMessageParams{Embed: embed})
+	paginator := &Paginator{
+		Pages:    ctx.Router.helpRenderer().CommandList(ctx, ctx.Router.Commands),
+		Controls: []PageControl{PagePrevious, PageClose, PageNext},
+	}
+	_, _ = paginator.Send(ctx)
+}
 
-	one := c.Channel(channelID).Message(message.ID).Reaction("⬅")
-	_ = one.Create()
-	two := c.Channel(channelID).Message(message.ID).Reaction("❌")
-	_ = two.Create()
-	three := c.Channel(channelID).Message(message.ID).Reaction("➡")
-	_ = three.Create()
+func specificHelpCommand(ctx *Ctx) {
+	command := ctx.Router.GetCmd(ctx.Args.Get(0).Raw())
+	for index := 1; index < ctx.Args.Amount(); index++ {
+		command = ctx.Router.GetSubCmd(command, ctx.Args.Get(index).Raw())
+	}
 
-	ctx.Router.Storage["hdl_helpMessages"].Set(fmt.Sprintf("%v:%v:%v", channelID, message.ID, ctx.Event.Message.Author.ID), 1)
+	_ = ctx.ResponseEmbed(ctx.Router.helpRenderer().CommandHelp(ctx, command))
 }
 
-func specificHelpCommand(ctx *Ctx) {
-	commandNames := strings.Split(ctx.Args.Raw(), " ")
+// texts returns the translation for the locale of the given context, texts it leaves empty are taken from
+// EnglishHelpStrings
+func (h *DefaultHelpRenderer) texts(ctx *Ctx) *HelpStrings {
+	if translation, ok := h.Translations[ctx.locale()]; ok && translation != nil {
+		return translation.withDefaults(EnglishHelpStrings)
+	}
+	return EnglishHelpStrings
+}
 
-	var command *Command
-	for index, commandName := range commandNames {
-		if index == 0 {
-			command = ctx.Router.GetCmd(commandName)
-			continue
+// withDefaults returns a copy of the texts whose empty texts are replaced by the given defaults
+func (s *HelpStrings) withDefaults(defaults *HelpStrings) *HelpStrings {
+	merged := *s
+	value := reflect.ValueOf(&merged).Elem()
+	fallback := reflect.ValueOf(defaults).Elem()
+	for index := 0; index < value.NumField(); index++ {
+		if value.Field(index).String() == "" {
+			value.Field(index).Set(fallback.Field(index))
 		}
-		command = command.GetSubCommand(commandName)
 	}
+	return &merged
+}
 
-	_, _ = ctx.Client.Channel(ctx.Event.Message.ChannelID).CreateMessage(&disgord.CreateMessageParams{Embed: renderDefaultSpecificHelpEmbed(ctx, command)})
+func (h *DefaultHelpRenderer) field(name, value string) *disgord.EmbedField {
+	return &disgord.EmbedField{
+		Name:   name,
+		Value:  value,
+		Inline: h.InlineFields,
+	}
 }
 
-func renderDefaultSpecificHelpEmbed(ctx *Ctx, command *Command) *disgord.Embed {
-	prefix := ctx.Router.Prefixes[0]
+func (h *DefaultHelpRenderer) CommandHelp(ctx *Ctx, command *Command) *disgord.Embed {
+	texts := h.texts(ctx)
+	prefix := helpPrefix(ctx)
 
 	if command == nil {
 		return &disgord.Embed{
 			Type:  "rich",
-			Title: "Error",
+			Title: texts.ErrorTitle,
 			Timestamp: disgord.Time{
 				Time: time.Now(),
 			},
-			Color: 0xff0000,
+			Color: h.ErrorColor,
 			Fields: []*disgord.EmbedField{
-				{
-					Name:   "Message",
-					Value:  "```The given command doesn't exist. Type `" + prefix + "help` for a list of available commands.```",
-					Inline: false,
-				},
+				h.field(texts.ErrorField, fmt.Sprintf(texts.UnknownCommand, prefix)),
 			},
 		}
 	}
 
-	subCommands := "No sub commands"
+	subCommands := texts.NoSubCommands
 	if len(command.SubCommands) > 0 {
 		subCommandNames := make([]string, len(command.SubCommands))
 		for index, subCommand := range command.SubCommands {
@@ -137,90 +212,87 @@ func renderDefaultSpecificHelpEmbed(ctx *Ctx, command *Command) *disgord.Embed {
 		subCommands = "`" + strings.Join(subCommandNames, "`, `") + "`"
 	}
 
-	aliases := "No aliases"
+	aliases := texts.NoAliases
 	if len(command.Aliases) > 0 {
 		aliases = "`" + strings.Join(command.Aliases, "`, `") + "`"
 	}
 
+	fields := []*disgord.EmbedField{
+		h.field(texts.Name, "`"+command.Name+"`"),
+		h.field(texts.SubCommands, subCommands),
+		h.field(texts.Aliases, aliases),
+		h.field(texts.Description, "```"+command.Description+"```"),
+	}
+
+	if len(command.Params) > 0 {
+		params := make([]string, len(command.Params))
+		for index, param := range command.Params {
+			params[index] = "`" + param.usage() + "` (" + ctx.Router.paramDisplay(param.paramType()) + ") " + param.Description
+		}
+		fields = append(fields, h.field(texts.Arguments, strings.Join(params, "\n")))
+	}
+
+	if len(command.Flags) > 0 {
+		flags := make([]string, len(command.Flags))
+		for index, flag := range command.Flags {
+			flags[index] = "`" + flag.usage(ctx.Router.paramDisplay(flag.paramType())) + "` " + flag.Description
+		}
+		fields = append(fields, h.field(texts.Flags, strings.Join(flags, "\n")))
+	}
+
+	fields = append(fields,
+		h.field(texts.Usage, "```"+prefix+command.UsageString()+"```"),
+		h.field(texts.Example, "```"+prefix+command.Example+"```"),
+	)
+
 	return &disgord.Embed{
-		Title:       "Command Information",
+		Title:       texts.CommandTitle,
 		Type:        "rich",
-		Description: "Displaying the information for thr `" + command.Name + "` command.",
+		Description: fmt.Sprintf(texts.CommandDescription, command.Name),
 		Timestamp: disgord.Time{
 			Time: time.Now(),
 		},
-		Color: 0xffff00,
-		Fields: []*disgord.EmbedField{
-			{
-				Name:   "Name",
-				Value:  "`" + command.Name + "`",
-				Inline: false,
-			},
-			{
-				Name:   "Sub Commands",
-				Value:  subCommands,
-				Inline: false,
-			},
-			{
-				Name:   "Aliases",
-				Value:  aliases,
-				Inline: false,
-			},
-			{
-				Name:   "Description",
-				Value:  "```" + command.Description + "```",
-				Inline: false,
-			},
-			{
-				Name:   "Usage",
-				Value:  "```" + prefix + command.Usage + "```",
-				Inline: false,
-			},
-			{
-				Name:   "Example",
-				Value:  "```" + prefix + command.Example + "```",
-				Inline: false,
-			},
-		},
+		Color:  h.Color,
+		Fields: fields,
 	}
 }
 
-func renderDefaultGeneralHelpEmbed(r *Router, page int) (*disgord.Embed, int) {
-	commands := r.Commands
-	prefix := r.Prefixes[0]
+func (h *DefaultHelpRenderer) CommandList(ctx *Ctx, commands []*Command) []*disgord.Embed {
+	texts := h.texts(ctx)
+	prefix := helpPrefix(ctx)
 
-	pageAmount := int(math.Ceil(float64(len(commands)) / 5))
-	if page > pageAmount {
-		page = pageAmount
+	perPage := h.CommandsPerPage
+	if perPage <= 0 {
+		perPage = 5
 	}
-	if page <= 0 {
-		page = 1
+	pageAmount := (len(commands) + perPage - 1) / perPage
+	if pageAmount == 0 {
+		pageAmount = 1
 	}
 
-	startingIndex := (page - 1) * 5
-	endingIndex := startingIndex + 5
-	if page == pageAmount {
-		endingIndex = len(commands)
-	}
-	displayCommands := commands[startingIndex:endingIndex]
+	pages := make([]*disgord.Embed, pageAmount)
+	for page := range pages {
+		startingIndex := page * perPage
+		endingIndex := startingIndex + perPage
+		if endingIndex > len(commands) {
+			endingIndex = len(commands)
+		}
 
-	fields := make([]*disgord.EmbedField, len(displayCommands))
-	for index, command := range displayCommands {
-		fields[index] = &disgord.EmbedField{
-			Name:   command.Name,
-			Value:  "`" + command.Description + "`",
-			Inline: false,
+		fields := make([]*disgord.EmbedField, 0, endingIndex-startingIndex)
+		for _, command := range commands[startingIndex:endingIndex] {
+			fields = append(fields, h.field(command.Name, "`"+command.Description+"`"))
 		}
-	}
 
-	return &disgord.Embed{
-		Title:       fmt.Sprintf("Command List (Page %s / %s)", strconv.Itoa(page), strconv.Itoa(pageAmount)),
-		Type:        "rich",
-		Description: fmt.Sprintf("These are all the available commands. Type `%shelp <command_name> to find out more about a specific command.`", prefix),
-		Timestamp: disgord.Time{
-			Time: time.Now(),
-		},
-		Color:  0xffff00,
-		Fields: fields,
-	}, page
+		pages[page] = &disgord.Embed{
+			Title:       fmt.Sprintf(texts.ListTitle, page+1, pageAmount),
+			Type:        "rich",
+			Description: fmt.Sprintf(texts.ListDescription, prefix),
+			Timestamp: disgord.Time{
+				Time: time.Now(),
+			},
+			Color:  h.Color,
+			Fields: fields,
+		}
+	}
+	return pages
 }
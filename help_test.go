@@ -0,0 +1,137 @@
+package cmdlr2_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zackartz/cmdlr2"
+)
+
+func TestHelpPagination(t *testing.T) {
+	h := newHarness()
+	for i := 0; i < 6; i++ {
+		h.Router.RegisterCMD(echoCommand(fmt.Sprintf("cmd%d", i)))
+	}
+	h.Router.RegisterDefaultHelpCommand()
+
+	replies := h.Send("!help")
+	if len(replies) != 1 || len(replies[0].Embeds) != 1 {
+		t.Fatalf("expected one embed reply, got %v", replies)
+	}
+	help := replies[0]
+	if title := help.Embeds[0].Title; title != "Command List (Page 1 / 2)" {
+		t.Fatalf("unexpected title %q", title)
+	}
+	if reactions := strings.Join(h.Transport.Reactions(help.ID), ""); reactions != "⬅❌➡" {
+		t.Errorf("unexpected reactions %q", reactions)
+	}
+
+	h.React(help.ID, "➡")
+	if title := h.Transport.Message(help.ID).Embeds[0].Title; title != "Command List (Page 2 / 2)" {
+		t.Errorf("expected second page, got %q", title)
+	}
+	if fields := len(h.Transport.Message(help.ID).Embeds[0].Fields); fields != 2 {
+		t.Errorf("expected 2 commands on the last page, got %d", fields)
+	}
+
+	h.React(help.ID, "➡")
+	if title := h.Transport.Message(help.ID).Embeds[0].Title; title != "Command List (Page 2 / 2)" {
+		t.Errorf("expected to stay on the last page, got %q", title)
+	}
+
+	h.ReactAs(h.Bot, help.ID, "⬅")
+	if title := h.Transport.Message(help.ID).Embeds[0].Title; title != "Command List (Page 2 / 2)" {
+		t.Errorf("expected reactions of the bot to be ignored, got %q", title)
+	}
+
+	h.React(help.ID, "⬅")
+	if title := h.Transport.Message(help.ID).Embeds[0].Title; title != "Command List (Page 1 / 2)" {
+		t.Errorf("expected first page, got %q", title)
+	}
+
+	h.React(help.ID, "❌")
+	if h.Transport.Message(help.ID) != nil {
+		t.Error("expected the help message to be deleted")
+	}
+}
+
+func TestSpecificHelp(t *testing.T) {
+	h := newHarness()
+	cmd := echoCommand("config", "cfg")
+	cmd.SubCommands = append(cmd.SubCommands, echoCommand("set"))
+	h.Router.RegisterCMD(cmd)
+	h.Router.RegisterDefaultHelpCommand()
+
+	for _, input := range []string{"!help config set", "!help  config   set", `!help "config" 'set'`} {
+		replies := h.Send(input)
+		if len(replies) != 1 || replies[0].Embeds[0].Fields[0].Value != "`set`" {
+			t.Errorf("%s: expected the help of the sub command, got %v", input, replies)
+		}
+	}
+
+	replies := h.Send("!help unknown")
+	if len(replies) != 1 || replies[0].Embeds[0].Title != "Error" {
+		t.Fatalf("expected an error embed, got %v", replies)
+	}
+}
+
+func TestHelpMentionPrefix(t *testing.T) {
+	h := newHarness()
+	ping := echoCommand("ping")
+	ping.Usage = "ping"
+	h.Router.RegisterCMD(ping)
+	h.Router.RegisterDefaultHelpCommand()
+	h.Router.MentionPrefix = true
+
+	for _, mention := range []string{"<@" + h.Bot.ID.String() + ">", "<@!" + h.Bot.ID.String() + ">"} {
+		replies := h.Send(mention + " help ping")
+		if len(replies) != 1 || replies[0].Embeds[0].Fields[4].Value != "```@bot ping```" {
+			t.Errorf("%s: expected the mention to be shown as @bot, got %v", mention, replies)
+		}
+	}
+}
+
+func TestHelpRenderer(t *testing.T) {
+	h := newHarness()
+	ping := echoCommand("ping")
+	ping.Usage = "ping"
+	h.Router.RegisterCMD(ping)
+	h.Router.RegisterDefaultHelpCommand()
+
+	replies := h.Send("?help ping")
+	if description := replies[0].Embeds[0].Description; description != "Displaying the information for the `ping` command." {
+		t.Errorf("unexpected description %q", description)
+	}
+	if usage := replies[0].Embeds[0].Fields[4].Value; usage != "```?ping```" {
+		t.Errorf("expected the typed prefix in the usage, got %q", usage)
+	}
+
+	renderer := cmdlr2.NewDefaultHelpRenderer()
+	renderer.Color = 0x00ff00
+	renderer.Translations["de"] = &cmdlr2.HelpStrings{
+		ListTitle:    "Befehle (Seite %d / %d)",
+		CommandTitle: "Befehl",
+	}
+	h.Router.HelpRenderer = renderer
+	h.Router.Locale = func(ctx *cmdlr2.Ctx) string {
+		return "de"
+	}
+
+	embed := h.Send("?help")[0].Embeds[0]
+	if embed.Title != "Befehle (Seite 1 / 1)" || embed.Color != 0x00ff00 {
+		t.Errorf("expected a green german command list, got %q and %x", embed.Title, embed.Color)
+	}
+	if !strings.Contains(embed.Description, "`?help <command_name>`") {
+		t.Errorf("expected the typed prefix in the description, got %q", embed.Description)
+	}
+
+	embed = h.Send("?help ping")[0].Embeds[0]
+	if embed.Title != "Befehl" || embed.Description != "Displaying the information for the `ping` command." ||
+		embed.Fields[0].Name != "Name" {
+		t.Errorf("expected missing translations to be english, got %q, %q and %q", embed.Title, embed.Description, embed.Fields[0].Name)
+	}
+	if cmdlr2.EnglishHelpStrings.CommandTitle != "Command Information" {
+		t.Errorf("expected the english texts to be left untouched")
+	}
+}
@@ -0,0 +1,222 @@
+package cmdlr2
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DuplicateCommandError is returned if a command is registered whose name or alias collides with another command on
+// the same level
+type DuplicateCommandError struct {
+	// Path is the space separated name of the parent command, empty for top level commands
+	Path     string
+	Name     string
+	Command  *Command
+	Existing *Command
+}
+
+func (e *DuplicateCommandError) Error() string {
+	name := e.Name
+	if e.Path != "" {
+		name = e.Path + " " + name
+	}
+	return fmt.Sprintf("command `%s` of `%s` collides with `%s`", name, e.Command.Name, e.Existing.Name)
+}
+
+// findDuplicate checks the given commands against the existing ones and each other. The sub commands of the given
+// commands are checked recursively.
+func findDuplicate(path string, existing, commands []*Command) *DuplicateCommandError {
+	type key struct {
+		name    string
+		command *Command
+	}
+	var seen []key
+
+	add := func(command *Command) *DuplicateCommandError {
+		names := append([]string{command.Name}, command.Aliases...)
+		for _, name := range names {
+			for _, other := range seen {
+				if other.command == command {
+					continue
+				}
+				ignoreCase := command.IgnoreCase || other.command.IgnoreCase
+				if Equals(name, other.name, ignoreCase) {
+					return &DuplicateCommandError{Path: path, Name: name, Command: command, Existing: other.command}
+				}
+			}
+		}
+		for _, name := range names {
+			seen = append(seen, key{name: name, command: command})
+		}
+		return nil
+	}
+
+	for _, command := range existing {
+		_ = add(command)
+	}
+	for _, command := range commands {
+		if err := add(command); err != nil {
+			return err
+		}
+	}
+
+	for _, command := range commands {
+		subPath := strings.TrimSpace(path + " " + command.Name)
+		if err := findDuplicate(subPath, nil, command.SubCommands); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commandIndex holds the tries of all commands and sub commands of a router. It is immutable once built so it can be
+// used by concurrent message handlers without locking.
+type commandIndex struct {
+	commands    *commandTrie
+	subCommands map[*Command]*commandTrie
+}
+
+func newCommandIndex(commands []*Command) *commandIndex {
+	index := &commandIndex{
+		commands:    newCommandTrie(commands),
+		subCommands: map[*Command]*commandTrie{},
+	}
+	index.addSubCommands(commands)
+	return index
+}
+
+func (i *commandIndex) addSubCommands(commands []*Command) {
+	for _, command := range commands {
+		if _, ok := i.subCommands[command]; ok {
+			continue
+		}
+		i.subCommands[command] = newCommandTrie(command.SubCommands)
+		i.addSubCommands(command.SubCommands)
+	}
+}
+
+// commandTrie resolves command names and aliases. Keys are stored case-folded, whether a command matches
+// case-insensitively is decided by its IgnoreCase setting when resolving.
+type commandTrie struct {
+	root *trieNode
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	entries  []*trieEntry
+}
+
+type trieEntry struct {
+	name    string
+	command *Command
+}
+
+func newCommandTrie(commands []*Command) *commandTrie {
+	trie := &commandTrie{root: &trieNode{}}
+	for _, command := range commands {
+		trie.insert(command.Name, command)
+		for _, alias := range command.Aliases {
+			trie.insert(alias, command)
+		}
+	}
+	return trie
+}
+
+func (t *commandTrie) insert(name string, command *Command) {
+	if name == "" {
+		return
+	}
+
+	node := t.root
+	for _, r := range name {
+		r = unicode.ToLower(r)
+		child, ok := node.children[r]
+		if !ok {
+			if node.children == nil {
+				node.children = map[rune]*trieNode{}
+			}
+			child = &trieNode{}
+			node.children[r] = child
+		}
+		node = child
+	}
+	node.entries = append(node.entries, &trieEntry{name: name, command: command})
+}
+
+// matchEntry returns the first entry of the node matching the given string
+func (n *trieNode) matchEntry(str string) *trieEntry {
+	for _, entry := range n.entries {
+		if entry.name == str || (entry.command.IgnoreCase && Equals(entry.name, str, true)) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// find returns the node of the given case-folded name
+func (t *commandTrie) find(name string) *trieNode {
+	node := t.root
+	for _, r := range name {
+		node = node.children[unicode.ToLower(r)]
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// lookup returns the command with exactly the given name or alias
+func (t *commandTrie) lookup(name string) *Command {
+	node := t.find(name)
+	if node == nil {
+		return nil
+	}
+
+	if entry := node.matchEntry(name); entry != nil {
+		return entry.command
+	}
+	return nil
+}
+
+// lookupApplicationCommand returns the command whose application command name is the given name. Aliases don't match
+// as they aren't registered as application commands.
+func (t *commandTrie) lookupApplicationCommand(name string) *Command {
+	node := t.find(name)
+	if node == nil {
+		return nil
+	}
+
+	for _, entry := range node.entries {
+		if entry.name == entry.command.Name && applicationCommandName(entry.name) == name {
+			return entry.command
+		}
+	}
+	return nil
+}
+
+// match resolves the command whose name or alias is the longest prefix of the given content which is followed by a
+// space, a newline or the end of the content. It returns the command and the length of the matched name.
+func (t *commandTrie) match(content string) (*Command, int) {
+	var command *Command
+	length := 0
+
+	node := t.root
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		node = node.children[unicode.ToLower(r)]
+		if node == nil {
+			break
+		}
+		i += size
+
+		if len(node.entries) > 0 && (i == len(content) || content[i] == ' ' || content[i] == '\n') {
+			if entry := node.matchEntry(content[:i]); entry != nil {
+				command, length = entry.command, i
+			}
+		}
+	}
+
+	return command, length
+}
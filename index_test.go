@@ -0,0 +1,157 @@
+package cmdlr2_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+)
+
+func TestLongestMatch(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMDList([]*cmdlr2.Command{
+		echoCommand("git"),
+		echoCommand("git log"),
+		echoCommand("b"),
+		echoCommand("ban"),
+	})
+
+	assertReplies(t, "!git log -n 1", send(h, "!git log -n 1"), "git log:-n 1")
+	assertReplies(t, "!git logs", send(h, "!git logs"), "git:logs")
+	assertReplies(t, "!ban x", send(h, "!ban x"), "ban:x")
+	assertReplies(t, "!b x", send(h, "!b x"), "b:x")
+}
+
+func TestGetSubCommand(t *testing.T) {
+	config := echoCommand("config")
+	set := echoCommand("set", "s")
+	get := echoCommand("get", "g")
+	get.IgnoreCase = true
+	config.SubCommands = []*cmdlr2.Command{set, get}
+
+	// A router not made by Create builds its index on the first lookup
+	router := &cmdlr2.Router{Commands: []*cmdlr2.Command{config}}
+	tests := map[string]*cmdlr2.Command{"set": set, "s": set, "SET": nil, "G": get, "Get": get, "": nil, "x": nil}
+	for name, expected := range tests {
+		if command := config.GetSubCommand(name); command != expected {
+			t.Errorf("GetSubCommand(%q): expected %v, got %v", name, expected, command)
+		}
+		if command := router.GetSubCmd(config, name); command != expected {
+			t.Errorf("GetSubCmd(%q): expected %v, got %v", name, expected, command)
+		}
+	}
+	if router.GetCmd("config") != config {
+		t.Error("expected the command to be found")
+	}
+}
+
+func benchmarkRouter(commands int) *cmdlr2.Router {
+	router := cmdlr2.Create(&cmdlr2.Router{Prefixes: []string{"!"}})
+	router.InitializeTransport(cmdlr2.NewMemoryTransport(&disgord.User{ID: 1, Bot: true}))
+
+	list := make([]*cmdlr2.Command, commands)
+	for i := 0; i < commands; i++ {
+		command := &cmdlr2.Command{
+			Name:       fmt.Sprintf("command%d", i),
+			Aliases:    []string{fmt.Sprintf("c%d", i), fmt.Sprintf("cmd%d", i)},
+			IgnoreCase: i%2 == 0,
+			Handler:    func(ctx *cmdlr2.Ctx) {},
+		}
+		for j := 0; j < 10; j++ {
+			command.SubCommands = append(command.SubCommands, &cmdlr2.Command{
+				Name:    fmt.Sprintf("sub%d", j),
+				Handler: func(ctx *cmdlr2.Ctx) {},
+			})
+		}
+		list[i] = command
+	}
+	router.RegisterCMDList(list)
+	return router
+}
+
+func BenchmarkGetCmd(b *testing.B) {
+	for _, commands := range []int{10, 100, 500, 1000} {
+		b.Run(fmt.Sprintf("%d", commands), func(b *testing.B) {
+			router := benchmarkRouter(commands)
+			name := fmt.Sprintf("CMD%d", commands-2)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if router.GetCmd(name) == nil {
+					b.Fatal("command not found")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetSubCmd(b *testing.B) {
+	router := benchmarkRouter(500)
+	command := router.GetCmd("command250")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if router.GetSubCmd(command, "sub9") == nil {
+			b.Fatal("sub command not found")
+		}
+	}
+}
+
+func BenchmarkHandleMessage(b *testing.B) {
+	for _, commands := range []int{10, 100, 500, 1000} {
+		b.Run(fmt.Sprintf("%d", commands), func(b *testing.B) {
+			router := benchmarkRouter(commands)
+			event := &disgord.MessageCreate{Message: &disgord.Message{
+				ChannelID: 2,
+				Author:    &disgord.User{ID: 3},
+				Content:   fmt.Sprintf("!command%d sub5 some arguments", commands-1),
+			}}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					router.HandleMessage(event)
+				}
+			})
+		})
+	}
+}
+
+func TestDuplicateCommands(t *testing.T) {
+	h := newHarness()
+	if err := h.Router.RegisterCMD(echoCommand("ban", "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Router.RegisterCMD(echoCommand("block", "b")); err == nil {
+		t.Error("expected colliding alias to be rejected")
+	}
+	if h.Router.GetCmd("block") != nil {
+		t.Error("expected rejected command not to be registered")
+	}
+
+	upper := echoCommand("BAN")
+	if err := h.Router.RegisterCMD(upper); err != nil {
+		t.Errorf("expected case-sensitive commands not to collide, got %v", err)
+	}
+	lower := echoCommand("bAn")
+	lower.IgnoreCase = true
+	if err := h.Router.RegisterCMD(lower); err == nil {
+		t.Error("expected case-insensitive collision to be rejected")
+	}
+
+	parent := echoCommand("config")
+	parent.SubCommands = []*cmdlr2.Command{echoCommand("set", "s"), echoCommand("show", "s")}
+	err := h.Router.RegisterCMD(parent)
+	if dup, ok := err.(*cmdlr2.DuplicateCommandError); !ok || dup.Path != "config" || dup.Name != "s" {
+		t.Errorf("expected sub command collision, got %v", err)
+	}
+
+	if err := h.Router.RegisterCMDList([]*cmdlr2.Command{echoCommand("x"), echoCommand("x")}); err == nil {
+		t.Error("expected collision within the registered list to be rejected")
+	}
+	if h.Router.GetCmd("x") != nil {
+		t.Error("expected no command of a rejected list to be registered")
+	}
+}
@@ -0,0 +1,578 @@
+package cmdlr2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/andersfylling/disgord"
+)
+
+// ErrInteractionsUnsupported is returned if application commands are used with a transport not implementing
+// InteractionSource. The DisgordTransport doesn't support interactions as disgord doesn't expose them, the
+// DisgordInteractionTransport receives them at the interactions endpoint instead.
+var ErrInteractionsUnsupported = errors.New("the transport doesn't support interactions")
+
+// InteractionType is the type of an incoming interaction
+type InteractionType int
+
+const (
+	InteractionPing               InteractionType = 1
+	InteractionApplicationCommand InteractionType = 2
+	// InteractionMessageComponent is sent when a user clicks a button of a message
+	InteractionMessageComponent InteractionType = 3
+	// InteractionAutocomplete asks for suggestions for the focused option of an application command
+	InteractionAutocomplete InteractionType = 4
+)
+
+// ApplicationCommandOptionType is the type of an application command option
+type ApplicationCommandOptionType int
+
+const (
+	OptionSubCommand      ApplicationCommandOptionType = 1
+	OptionSubCommandGroup ApplicationCommandOptionType = 2
+	OptionString          ApplicationCommandOptionType = 3
+	OptionInteger         ApplicationCommandOptionType = 4
+	OptionBoolean         ApplicationCommandOptionType = 5
+	OptionUser            ApplicationCommandOptionType = 6
+	OptionChannel         ApplicationCommandOptionType = 7
+	OptionRole            ApplicationCommandOptionType = 8
+)
+
+// ApplicationCommand is a slash command as registered at Discord
+type ApplicationCommand struct {
+	ID          disgord.Snowflake           `json:"id,omitempty"`
+	Name        string                      `json:"name"`
+	Description string                      `json:"description"`
+	Options     []*ApplicationCommandOption `json:"options,omitempty"`
+}
+
+// ApplicationCommandOption is a parameter or sub command of an application command
+type ApplicationCommandOption struct {
+	Type        ApplicationCommandOptionType      `json:"type"`
+	Name        string                            `json:"name"`
+	Description string                            `json:"description"`
+	Required    bool                              `json:"required,omitempty"`
+	Choices     []*ApplicationCommandOptionChoice `json:"choices,omitempty"`
+	Options     []*ApplicationCommandOption       `json:"options,omitempty"`
+	// Autocomplete is set for options of custom types offering suggestions
+	Autocomplete bool `json:"autocomplete,omitempty"`
+}
+
+// ApplicationCommandOptionChoice is a predefined value of an application command option
+type ApplicationCommandOptionChoice struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// Interaction is an incoming slash command invocation
+type Interaction struct {
+	ID        disgord.Snowflake `json:"id"`
+	Type      InteractionType   `json:"type"`
+	Data      *InteractionData  `json:"data"`
+	GuildID   disgord.Snowflake `json:"guild_id"`
+	ChannelID disgord.Snowflake `json:"channel_id"`
+	// Member is set for interactions in guilds, User for interactions in direct messages
+	Member *disgord.Member `json:"member"`
+	User   *disgord.User   `json:"user"`
+	Token  string          `json:"token"`
+	// Message is the message the clicked component belongs to
+	Message *disgord.Message `json:"message,omitempty"`
+}
+
+// InteractionData holds the invoked application command and its options
+type InteractionData struct {
+	ID      disgord.Snowflake        `json:"id"`
+	Name    string                   `json:"name"`
+	Options []*InteractionDataOption `json:"options"`
+	// CustomID identifies the clicked component
+	CustomID string `json:"custom_id,omitempty"`
+}
+
+// InteractionDataOption is the value of a single option or an invoked sub command
+type InteractionDataOption struct {
+	Name    string                       `json:"name"`
+	Type    ApplicationCommandOptionType `json:"type"`
+	Value   interface{}                  `json:"value"`
+	Options []*InteractionDataOption     `json:"options"`
+	// Focused is set for the option which is autocompleted
+	Focused bool `json:"focused,omitempty"`
+}
+
+// Author returns the user who invoked the interaction
+func (i *Interaction) Author() *disgord.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// message converts the interaction into a message with the given content for the handlers of the router
+func (i *Interaction) message(content string) *disgord.Message {
+	return &disgord.Message{
+		ID:        i.ID,
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		Author:    i.Author(),
+		Member:    i.Member,
+		Content:   content,
+	}
+}
+
+// InteractionSource is implemented by transports supporting application commands
+type InteractionSource interface {
+	// OnInteractionCreate registers a handler which gets called for every incoming interaction
+	OnInteractionCreate(handler func(interaction *Interaction))
+
+	// SetApplicationCommands overwrites all application commands of the given guild or the global ones if the
+	// guild ID is zero
+	SetApplicationCommands(ctx context.Context, guildID disgord.Snowflake, commands []*ApplicationCommand) error
+
+	// CreateInteractionResponse sends the initial response to the given interaction
+	CreateInteractionResponse(ctx context.Context, interaction *Interaction, params *disgord.CreateMessageParams) (*disgord.Message, error)
+
+	// CreateFollowupMessage sends another response to the given, already answered interaction
+	CreateFollowupMessage(ctx context.Context, interaction *Interaction, params *disgord.CreateMessageParams) (*disgord.Message, error)
+}
+
+// AutocompleteResponder is implemented by interaction sources supporting the autocompletion of options
+type AutocompleteResponder interface {
+	// CreateAutocompleteResponse answers the given autocomplete interaction with the given suggestions
+	CreateAutocompleteResponse(ctx context.Context, interaction *Interaction, choices []*ApplicationCommandOptionChoice) error
+}
+
+// interactionResponder answers the first message of a handler as the interaction response and all further ones as
+// follow up messages. Everything else is passed to the underlying responder.
+type interactionResponder struct {
+	Responder
+	source      InteractionSource
+	interaction *Interaction
+	mutex       sync.Mutex
+	responded   bool
+}
+
+func (r *interactionResponder) SendMessage(ctx context.Context, channelID disgord.Snowflake, params *disgord.CreateMessageParams) (*disgord.Message, error) {
+	if channelID != r.interaction.ChannelID {
+		return r.Responder.SendMessage(ctx, channelID, params)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.responded {
+		return r.source.CreateFollowupMessage(ctx, r.interaction, params)
+	}
+	message, err := r.source.CreateInteractionResponse(ctx, r.interaction, params)
+	if err == nil {
+		r.responded = true
+	}
+	return message, err
+}
+
+// ApplicationCommands converts the registered commands into application commands. Aliases are not registered, sub
+// commands are only supported up to two levels deep as Discord doesn't allow deeper nesting. Sub commands having sub
+// commands themselves are registered as sub command groups.
+func (r *Router) ApplicationCommands() []*ApplicationCommand {
+	r.mutex.RLock()
+	registered := append([]*Command(nil), r.Commands...)
+	r.mutex.RUnlock()
+
+	commands := make([]*ApplicationCommand, len(registered))
+	for index, command := range registered {
+		commands[index] = &ApplicationCommand{
+			Name:        applicationCommandName(command.Name),
+			Description: applicationCommandDescription(command),
+			Options:     applicationCommandOptions(r, command, 0),
+		}
+	}
+	return commands
+}
+
+// RegisterApplicationCommands registers all commands as application commands of the given guild or globally if the
+// guild ID is zero. It returns an *InvalidNameError before talking to Discord if the name of a command, parameter or
+// flag isn't allowed for application commands, for example a multi word name like `git log`.
+func (r *Router) RegisterApplicationCommands(ctx context.Context, guildID disgord.Snowflake) error {
+	source, ok := r.Transport.(InteractionSource)
+	if !ok {
+		return ErrInteractionsUnsupported
+	}
+
+	r.mutex.RLock()
+	err := findInvalidName("", r.Commands)
+	r.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+	return source.SetApplicationCommands(ctx, guildID, r.ApplicationCommands())
+}
+
+func applicationCommandName(name string) string {
+	return strings.ToLower(name)
+}
+
+// applicationCommandNamePattern matches the names Discord accepts for application commands and their options
+var applicationCommandNamePattern = regexp.MustCompile(`^[-_\p{L}\p{N}]{1,32}$`)
+
+// InvalidNameError is returned if application commands are registered for a command whose name or the name of one of
+// its parameters or flags can't be used as the name of an application command or option
+type InvalidNameError struct {
+	// Path is the space separated name of the command the name belongs to
+	Path string
+	Name string
+}
+
+func (e *InvalidNameError) Error() string {
+	return fmt.Sprintf("name `%s` of `%s` must consist of 1 to 32 letters, numbers, dashes or underscores", e.Name, e.Path)
+}
+
+// findInvalidName checks the names of the given commands, their parameters and flags and their sub commands
+func findInvalidName(path string, commands []*Command) *InvalidNameError {
+	for _, command := range commands {
+		commandPath := strings.TrimSpace(path + " " + command.Name)
+		names := []string{command.Name}
+		for _, param := range command.Params {
+			names = append(names, param.Name)
+		}
+		for _, flag := range command.Flags {
+			names = append(names, flag.Name)
+		}
+
+		for _, name := range names {
+			if !applicationCommandNamePattern.MatchString(name) {
+				return &InvalidNameError{Path: commandPath, Name: name}
+			}
+		}
+		if err := findInvalidName(commandPath, command.SubCommands); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applicationCommandDescription(command *Command) string {
+	description := command.Description
+	if description == "" {
+		description = command.Name
+	}
+	return truncateDescription(description)
+}
+
+// truncateDescription shortens the given description to the 100 characters allowed by Discord
+func truncateDescription(description string) string {
+	if utf8.RuneCountInString(description) <= 100 {
+		return description
+	}
+	return string([]rune(description)[:97]) + "..."
+}
+
+func applicationCommandOptions(r *Router, command *Command, depth int) []*ApplicationCommandOption {
+	if len(command.SubCommands) == 0 || depth >= 2 {
+		return parameterOptions(r, command)
+	}
+
+	options := make([]*ApplicationCommandOption, len(command.SubCommands))
+	for index, subCommand := range command.SubCommands {
+		option := &ApplicationCommandOption{
+			Type:        OptionSubCommand,
+			Name:        applicationCommandName(subCommand.Name),
+			Description: applicationCommandDescription(subCommand),
+		}
+		// Only the sub commands of top level commands can be groups
+		if depth == 0 && len(subCommand.SubCommands) > 0 {
+			option.Type = OptionSubCommandGroup
+			option.Options = applicationCommandOptions(r, subCommand, depth+1)
+		} else {
+			option.Options = parameterOptions(r, subCommand)
+		}
+		options[index] = option
+	}
+	return options
+}
+
+var paramOptionTypes = map[ParamType]ApplicationCommandOptionType{
+	ParamString:   OptionString,
+	ParamRest:     OptionString,
+	ParamDuration: OptionString,
+	ParamInt:      OptionInteger,
+	ParamBool:     OptionBoolean,
+	ParamUser:     OptionUser,
+	ParamRole:     OptionRole,
+	ParamChannel:  OptionChannel,
+}
+
+// parameterOptions converts the parameters and flags of the command into options. Commands without parameters get a
+// single free text option which is passed as the raw arguments.
+func parameterOptions(r *Router, command *Command) []*ApplicationCommandOption {
+	var options []*ApplicationCommandOption
+
+	for _, param := range command.Params {
+		option := &ApplicationCommandOption{
+			Type:        optionType(param.paramType()),
+			Name:        applicationCommandName(param.Name),
+			Description: truncateDescription(param.Description),
+			Required:    !param.Optional,
+		}
+		if option.Description == "" {
+			option.Description = param.Name
+		}
+		for _, choice := range param.Choices {
+			option.Choices = append(option.Choices, &ApplicationCommandOptionChoice{Name: choice, Value: choice})
+		}
+		if converter, ok := r.paramConverter(param.paramType()); ok && converter.complete != nil && len(param.Choices) == 0 {
+			option.Autocomplete = true
+		}
+		options = append(options, option)
+	}
+
+	for _, flag := range command.Flags {
+		option := &ApplicationCommandOption{
+			Type:        optionType(flag.paramType()),
+			Name:        applicationCommandName(flag.Name),
+			Description: truncateDescription(flag.Description),
+		}
+		if converter, ok := r.paramConverter(flag.paramType()); ok && converter.complete != nil {
+			option.Autocomplete = true
+		}
+		if option.Description == "" {
+			option.Description = flag.Name
+		}
+		options = append(options, option)
+	}
+
+	if len(command.Params) == 0 {
+		options = append(options, &ApplicationCommandOption{
+			Type:        OptionString,
+			Name:        "arguments",
+			Description: "The arguments of the command",
+		})
+	}
+
+	return options
+}
+
+func optionType(paramType ParamType) ApplicationCommandOptionType {
+	if t, ok := paramOptionTypes[paramType]; ok {
+		return t
+	}
+	return OptionString
+}
+
+// optionValue converts the value of an interaction option into the textual form used by the argument parsers
+func optionValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// findOption returns the option with the given name
+func findOption(options []*InteractionDataOption, name string) *InteractionDataOption {
+	for _, option := range options {
+		if option.Name == applicationCommandName(name) {
+			return option
+		}
+	}
+	return nil
+}
+
+// HandleInteraction dispatches the given interaction to the matching command. Autocomplete interactions are answered
+// with the suggestions of the type of the focused option, clicked components are passed to the component handlers.
+func (r *Router) HandleInteraction(interaction *Interaction) {
+	if interaction.Type == InteractionMessageComponent {
+		r.handleComponent(interaction)
+		return
+	}
+	if interaction.Data == nil ||
+		(interaction.Type != InteractionApplicationCommand && interaction.Type != InteractionAutocomplete) {
+		return
+	}
+
+	command := r.getApplicationCommand(nil, interaction.Data.Name)
+	if command == nil {
+		return
+	}
+
+	// Descend into the invoked sub commands
+	var parents []*Command
+	options := interaction.Data.Options
+	names := []string{command.Name}
+	for len(options) > 0 && (options[0].Type == OptionSubCommand || options[0].Type == OptionSubCommandGroup) {
+		subCommand := r.getApplicationCommand(command, options[0].Name)
+		if subCommand == nil {
+			return
+		}
+
+		parents = append(parents, command)
+		command = subCommand
+		names = append(names, command.Name)
+		options = options[0].Options
+	}
+
+	// Rebuild the raw arguments for handlers reading ctx.Args directly
+	var raw string
+	if len(command.Params) == 0 {
+		if option := findOption(options, "arguments"); option != nil {
+			raw = optionValue(option.Value)
+		}
+	} else {
+		var args []string
+		for _, param := range command.Params {
+			option := findOption(options, param.Name)
+			if option == nil {
+				break
+			}
+			value := optionValue(option.Value)
+			if param.paramType() != ParamRest {
+				value = quoteArgument(value)
+			}
+			args = append(args, value)
+		}
+		raw = strings.Join(args, " ")
+	}
+
+	event := &disgord.MessageCreate{Message: interaction.message("/" + strings.Join(append(names, raw), " "))}
+
+	ctx := r.newCtx(event, ParseArguments(raw), command)
+	ctx.Prefix = "/"
+	ctx.Interaction = interaction
+	ctx.options = options
+	if interaction.Type == InteractionAutocomplete {
+		if r.begin() {
+			defer r.running.Done()
+			r.autocomplete(ctx, command)
+		}
+		return
+	}
+	if source, ok := r.Transport.(InteractionSource); ok {
+		ctx.Responder = &interactionResponder{
+			Responder:   r.Transport,
+			source:      source,
+			interaction: interaction,
+		}
+	}
+
+	r.execute(ctx, func() {
+		command.trigger(ctx, parents)
+	})
+}
+
+// getApplicationCommand resolves the given application command name among the sub commands of the given command or
+// among the top level commands if it is nil
+func (r *Router) getApplicationCommand(command *Command, name string) *Command {
+	index := r.commandIndex()
+	if command == nil {
+		return index.commands.lookupApplicationCommand(name)
+	}
+	if trie, ok := index.subCommands[command]; ok {
+		return trie.lookupApplicationCommand(name)
+	}
+	return newCommandTrie(command.SubCommands).lookupApplicationCommand(name)
+}
+
+// autocomplete answers an autocomplete interaction with the suggestions of the type of the focused option
+func (r *Router) autocomplete(ctx *Ctx, command *Command) {
+	responder, ok := r.Transport.(AutocompleteResponder)
+	if !ok {
+		return
+	}
+
+	var focused *InteractionDataOption
+	for _, option := range ctx.options {
+		if option.Focused {
+			focused = option
+			break
+		}
+	}
+	if focused == nil {
+		return
+	}
+
+	var paramType ParamType
+	for _, param := range command.Params {
+		if applicationCommandName(param.Name) == focused.Name {
+			paramType = param.paramType()
+		}
+	}
+	for _, flag := range command.Flags {
+		if applicationCommandName(flag.Name) == focused.Name {
+			paramType = flag.paramType()
+		}
+	}
+
+	converter, ok := r.paramConverter(paramType)
+	if !ok || converter.complete == nil {
+		return
+	}
+
+	defer ctx.recoverPanic()
+	suggestions := converter.complete(ctx, optionValue(focused.Value))
+	// Discord allows at most 25 suggestions
+	if len(suggestions) > 25 {
+		suggestions = suggestions[:25]
+	}
+	choices := make([]*ApplicationCommandOptionChoice, len(suggestions))
+	for index, suggestion := range suggestions {
+		choices[index] = &ApplicationCommandOptionChoice{Name: suggestion, Value: suggestion}
+	}
+	_ = responder.CreateAutocompleteResponse(ctx.context(), ctx.Interaction, choices)
+}
+
+// parseInteractionArguments fills the flags and parameters of the command from the interaction options
+func (c *Command) parseInteractionArguments(ctx *Ctx) error {
+	flags := ParamValues{}
+	for _, flag := range c.Flags {
+		if option := findOption(ctx.options, flag.Name); option != nil {
+			converter, ok := ctx.paramConverter(flag.paramType())
+			if !ok {
+				return fmt.Errorf("unknown flag type %q", flag.Type)
+			}
+			value, err := converter.convert(ctx, optionValue(option.Value))
+			if err != nil {
+				err.Flag = flag
+				return err
+			}
+			flags[flag.Name] = value
+		} else if flag.Default != nil {
+			flags[flag.Name] = flag.Default
+		} else if flag.paramType() == ParamBool {
+			flags[flag.Name] = false
+		}
+	}
+	ctx.Flags = flags
+
+	params := ParamValues{}
+	for _, param := range c.Params {
+		option := findOption(ctx.options, param.Name)
+		if option == nil {
+			if !param.Optional {
+				return &ArgumentError{Param: param, Message: "is missing"}
+			}
+			if param.Default != nil {
+				params[param.Name] = param.Default
+			}
+			continue
+		}
+
+		converter, ok := ctx.paramConverter(param.paramType())
+		if !ok {
+			return fmt.Errorf("unknown parameter type %q", param.Type)
+		}
+		value, err := parseParam(ctx, param, converter, optionValue(option.Value))
+		if err != nil {
+			return err
+		}
+		params[param.Name] = value
+	}
+	ctx.Params = params
+
+	return nil
+}
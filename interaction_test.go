@@ -0,0 +1,206 @@
+package cmdlr2_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/zackartz/cmdlr2"
+	"github.com/zackartz/cmdlr2/cmdlrtest"
+)
+
+func newSlashHarness() *cmdlrtest.Harness {
+	h := newHarness()
+
+	ban := &cmdlr2.Command{
+		Name:        "Ban",
+		Description: "Bans a user",
+		Params: []*cmdlr2.Param{
+			{Name: "user", Type: cmdlr2.ParamUser},
+			{Name: "days", Type: cmdlr2.ParamInt, Optional: true, Default: 1, Max: cmdlr2.Limit(7)},
+			{Name: "reason", Type: cmdlr2.ParamRest, Optional: true},
+		},
+		Flags: []*cmdlr2.Flag{
+			{Name: "silent"},
+		},
+		Handler: func(ctx *cmdlr2.Ctx) {
+			_ = ctx.ResponseText(fmt.Sprintf("%v %d %q %v", ctx.Params.Snowflake("user"), ctx.Params.Int("days"), ctx.Params.String("reason"), ctx.Flags.Bool("silent")))
+			_ = ctx.ResponseText("done")
+		},
+	}
+
+	config := echoCommand("config")
+	config.SubCommands = []*cmdlr2.Command{echoCommand("set"), echoCommand("get")}
+
+	h.Router.RegisterCMDList([]*cmdlr2.Command{ban, config})
+	return h
+}
+
+func TestApplicationCommands(t *testing.T) {
+	h := newSlashHarness()
+	if err := h.Router.RegisterApplicationCommands(context.Background(), h.GuildID); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := h.Transport.ApplicationCommands(h.GuildID)
+	if len(commands) != 2 {
+		t.Fatalf("expected 2 application commands, got %d", len(commands))
+	}
+
+	ban := commands[0]
+	if ban.Name != "ban" || ban.Description != "Bans a user" || len(ban.Options) != 4 {
+		t.Fatalf("unexpected ban command %+v", ban)
+	}
+	expected := []struct {
+		name     string
+		t        cmdlr2.ApplicationCommandOptionType
+		required bool
+	}{
+		{"user", cmdlr2.OptionUser, true},
+		{"days", cmdlr2.OptionInteger, false},
+		{"reason", cmdlr2.OptionString, false},
+		{"silent", cmdlr2.OptionBoolean, false},
+	}
+	for index, option := range ban.Options {
+		e := expected[index]
+		if option.Name != e.name || option.Type != e.t || option.Required != e.required {
+			t.Errorf("unexpected option %+v, expected %+v", option, e)
+		}
+	}
+
+	config := commands[1]
+	if len(config.Options) != 2 || config.Options[0].Type != cmdlr2.OptionSubCommand || config.Options[0].Name != "set" {
+		t.Fatalf("unexpected config command %+v", config)
+	}
+	if len(config.Options[0].Options) != 1 || config.Options[0].Options[0].Name != "arguments" {
+		t.Errorf("expected free text arguments option, got %+v", config.Options[0].Options)
+	}
+}
+
+func TestInteractionDispatch(t *testing.T) {
+	h := newSlashHarness()
+
+	replies := h.Interact("ban",
+		cmdlrtest.Option("user", "123"),
+		cmdlrtest.Option("reason", "being rude"),
+		cmdlrtest.Option("silent", true),
+	)
+	if len(replies) != 2 {
+		t.Fatalf("expected 2 replies, got %d", len(replies))
+	}
+	if replies[0].Content != `123 1 "being rude" true` {
+		t.Errorf("unexpected reply %q", replies[0].Content)
+	}
+	for _, reply := range replies {
+		if h.Transport.ResponseOf(reply.ID) == nil {
+			t.Errorf("expected %q to be an interaction response", reply.Content)
+		}
+	}
+
+	replies = h.Interact("ban", cmdlrtest.Option("user", "123"), cmdlrtest.Option("days", float64(9)))
+	if len(replies) != 1 || replies[0].Content != "Invalid usage: invalid argument `days`: must be at most 7.\nUsage: `/Ban [flags] <user> [days] [reason...]`" {
+		t.Errorf("expected usage error, got %v", replies)
+	}
+
+	replies = h.Interact("config", cmdlrtest.SubCommand("set", cmdlrtest.Option("arguments", "a b")))
+	if len(replies) != 1 || replies[0].Content != "set:a b" {
+		t.Errorf("expected sub command reply, got %v", replies)
+	}
+
+	if replies := h.Interact("unknown"); len(replies) != 0 {
+		t.Errorf("expected no reply for unknown commands, got %v", replies)
+	}
+}
+
+func TestInteractionConcurrentRegistration(t *testing.T) {
+	h := newSlashHarness()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			h.Router.RegisterCMD(echoCommand(fmt.Sprintf("echo%d", i)))
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if replies := h.Interact("config", cmdlrtest.SubCommand("get")); len(replies) != 1 || replies[0].Content != "get:" {
+			t.Errorf("expected sub command reply, got %v", replies)
+		}
+		h.Router.ApplicationCommands()
+	}
+	<-done
+
+	if commands := h.Router.ApplicationCommands(); len(commands) != 22 {
+		t.Errorf("expected 22 application commands, got %d", len(commands))
+	}
+	if replies := h.Interact("echo19"); len(replies) != 1 || replies[0].Content != "echo19:" {
+		t.Errorf("expected the registered command to reply, got %v", replies)
+	}
+}
+
+func TestMixedSubCommandGroups(t *testing.T) {
+	h := newHarness()
+	set := echoCommand("set")
+	set.SubCommands = []*cmdlr2.Command{echoCommand("prefix"), echoCommand("color")}
+	get := &cmdlr2.Command{
+		Name:   "get",
+		Params: []*cmdlr2.Param{{Name: "key", Type: cmdlr2.ParamString}},
+		Handler: func(ctx *cmdlr2.Ctx) {
+			_ = ctx.ResponseText("get:" + ctx.Params.String("key"))
+		},
+	}
+	config := echoCommand("config")
+	config.SubCommands = []*cmdlr2.Command{get, set}
+	h.Router.RegisterCMD(config)
+
+	options := h.Router.ApplicationCommands()[0].Options
+	if len(options) != 2 || options[0].Type != cmdlr2.OptionSubCommand || options[1].Type != cmdlr2.OptionSubCommandGroup {
+		t.Fatalf("expected a leaf sub command and a group, got %+v", options)
+	}
+	if len(options[0].Options) != 1 || options[0].Options[0].Name != "key" || options[0].Options[0].Type != cmdlr2.OptionString {
+		t.Errorf("expected the leaf to keep its parameters, got %+v", options[0].Options)
+	}
+	for _, option := range options[1].Options {
+		if option.Type != cmdlr2.OptionSubCommand {
+			t.Errorf("expected the group to hold sub commands, got %+v", option)
+		}
+	}
+
+	replies := h.Interact("config", cmdlrtest.SubCommand("get", cmdlrtest.Option("key", "prefix")))
+	if len(replies) != 1 || replies[0].Content != "get:prefix" {
+		t.Errorf("expected the leaf to be invoked, got %v", replies)
+	}
+	replies = h.Interact("config", cmdlrtest.SubCommandGroup("set", cmdlrtest.SubCommand("color", cmdlrtest.Option("arguments", "red"))))
+	if len(replies) != 1 || replies[0].Content != "color:red" {
+		t.Errorf("expected the grouped sub command to be invoked, got %v", replies)
+	}
+}
+
+func TestApplicationCommandLimits(t *testing.T) {
+	h := newHarness()
+	long := echoCommand("long")
+	long.Description = strings.Repeat("ü", 120)
+	h.Router.RegisterCMD(long)
+
+	description := h.Router.ApplicationCommands()[0].Description
+	if !utf8.ValidString(description) || utf8.RuneCountInString(description) != 100 || !strings.HasSuffix(description, "üü...") {
+		t.Errorf("expected the description to be truncated to 100 characters, got %q", description)
+	}
+
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name:    "remind",
+		Params:  []*cmdlr2.Param{{Name: "remind at", Type: cmdlr2.ParamString}},
+		Handler: func(ctx *cmdlr2.Ctx) {},
+	})
+	err := h.Router.RegisterApplicationCommands(context.Background(), h.GuildID)
+	var invalidName *cmdlr2.InvalidNameError
+	if !errors.As(err, &invalidName) || invalidName.Path != "remind" || invalidName.Name != "remind at" {
+		t.Errorf("expected the parameter name to be rejected, got %v", err)
+	}
+	if commands := h.Transport.ApplicationCommands(h.GuildID); commands != nil {
+		t.Errorf("expected nothing to be registered, got %v", commands)
+	}
+}
@@ -0,0 +1,118 @@
+package cmdlr2
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/andersfylling/disgord"
+)
+
+// MentionType is the type of a mention found in the arguments
+type MentionType int
+
+const (
+	MentionUser MentionType = iota
+	MentionRole
+	MentionChannel
+	MentionEveryone
+	MentionHere
+	// MentionEmoji is a custom emoji like `<:name:id>`
+	MentionEmoji
+)
+
+// Mention is a mention or custom emoji found in the raw arguments
+type Mention struct {
+	Type MentionType
+	// ID is zero for @everyone and @here
+	ID disgord.Snowflake
+	// Name and Animated are only set for custom emojis
+	Name     string
+	Animated bool
+	// Start and End are the byte offsets of the mention in the raw string of the arguments
+	Start int
+	End   int
+}
+
+// regexMentions matches @everyone and @here only at the start or after whitespace, so e-mail addresses don't match
+var regexMentions = regexp.MustCompile("<@!?(\\d+)>|<@&(\\d+)>|<#(\\d+)>|(?:^|\\s)@(everyone|here)|<(a?):(\\w+):(\\d+)>")
+
+// Mentions returns the mentions of the given types in the order they appear in the raw arguments. If no types are
+// given, all mentions are returned.
+func (a *Arguments) Mentions(types ...MentionType) []*Mention {
+	var mentions []*Mention
+	for _, match := range regexMentions.FindAllStringSubmatchIndex(a.raw, -1) {
+		group := func(n int) string {
+			if match[2*n] < 0 {
+				return ""
+			}
+			return a.raw[match[2*n]:match[2*n+1]]
+		}
+
+		mention := &Mention{Start: match[0], End: match[1]}
+		switch {
+		case group(1) != "":
+			mention.Type, mention.ID = MentionUser, parseSnowflake(group(1))
+		case group(2) != "":
+			mention.Type, mention.ID = MentionRole, parseSnowflake(group(2))
+		case group(3) != "":
+			mention.Type, mention.ID = MentionChannel, parseSnowflake(group(3))
+		case group(4) == "everyone":
+			// the match may start with the whitespace in front of the @
+			mention.Type, mention.Start = MentionEveryone, match[8]-1
+		case group(4) == "here":
+			mention.Type, mention.Start = MentionHere, match[8]-1
+		default:
+			mention.Type, mention.ID = MentionEmoji, parseSnowflake(group(7))
+			mention.Name = group(6)
+			mention.Animated = group(5) == "a"
+		}
+
+		if len(types) == 0 || containsMentionType(types, mention.Type) {
+			mentions = append(mentions, mention)
+		}
+	}
+	return mentions
+}
+
+// MentionIDs returns the IDs of the mentions of the given type in the order they appear, duplicates are removed
+func (a *Arguments) MentionIDs(mentionType MentionType) []disgord.Snowflake {
+	var ids []disgord.Snowflake
+	seen := map[disgord.Snowflake]bool{}
+	for _, mention := range a.Mentions(mentionType) {
+		if !seen[mention.ID] {
+			seen[mention.ID] = true
+			ids = append(ids, mention.ID)
+		}
+	}
+	return ids
+}
+
+func containsMentionType(types []MentionType, mentionType MentionType) bool {
+	for _, t := range types {
+		if t == mentionType {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSnowflake parses the given string of digits, it returns zero if it overflows
+func parseSnowflake(raw string) disgord.Snowflake {
+	id, _ := strconv.ParseUint(raw, 10, 64)
+	return disgord.Snowflake(id)
+}
+
+// AsUserMention returns the ID of the mentioned user or zero if it is no mention
+func (arg *Argument) AsUserMention() disgord.Snowflake {
+	return parseSnowflake(arg.AsUserMentionID())
+}
+
+// AsRoleMention returns the ID of the mentioned role or zero if it is no mention
+func (arg *Argument) AsRoleMention() disgord.Snowflake {
+	return parseSnowflake(arg.AsRoleMentionID())
+}
+
+// AsChannelMention returns the ID of the mentioned channel or zero if it is no mention
+func (arg *Argument) AsChannelMention() disgord.Snowflake {
+	return parseSnowflake(arg.AsChannelMentionID())
+}
@@ -0,0 +1,61 @@
+package cmdlr2_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+)
+
+func TestMentions(t *testing.T) {
+	raw := "hey <@1> and <@!2>, see <#3> with <@&4> @everyone <a:wave:5> @here <@1>"
+	args := cmdlr2.ParseArguments(raw)
+
+	mentions := args.Mentions()
+	expected := []cmdlr2.Mention{
+		{Type: cmdlr2.MentionUser, ID: 1},
+		{Type: cmdlr2.MentionUser, ID: 2},
+		{Type: cmdlr2.MentionChannel, ID: 3},
+		{Type: cmdlr2.MentionRole, ID: 4},
+		{Type: cmdlr2.MentionEveryone},
+		{Type: cmdlr2.MentionEmoji, ID: 5, Name: "wave", Animated: true},
+		{Type: cmdlr2.MentionHere},
+		{Type: cmdlr2.MentionUser, ID: 1},
+	}
+	if len(mentions) != len(expected) {
+		t.Fatalf("expected %d mentions, got %d", len(expected), len(mentions))
+	}
+	for i, mention := range mentions {
+		span := raw[mention.Start:mention.End]
+		actual := *mention
+		actual.Start, actual.End = 0, 0
+		if actual != expected[i] {
+			t.Errorf("mention %d (%q): expected %+v, got %+v", i, span, expected[i], actual)
+		}
+	}
+	if span := raw[mentions[2].Start:mentions[2].End]; span != "<#3>" {
+		t.Errorf("expected the channel mention to span `<#3>`, got %q", span)
+	}
+
+	if ids := args.MentionIDs(cmdlr2.MentionUser); !reflect.DeepEqual(ids, []disgord.Snowflake{1, 2}) {
+		t.Errorf("unexpected user IDs %v", ids)
+	}
+	if roles := args.Mentions(cmdlr2.MentionRole, cmdlr2.MentionEveryone); len(roles) != 2 {
+		t.Errorf("expected the role and @everyone mention, got %d mentions", len(roles))
+	}
+
+	if span := raw[mentions[4].Start:mentions[4].End]; span != "@everyone" {
+		t.Errorf("expected the @everyone mention to span `@everyone`, got %q", span)
+	}
+	if mentions := cmdlr2.ParseArguments("@here mail x@here.com or a@everyone.org").Mentions(); len(mentions) != 1 || mentions[0].Start != 0 {
+		t.Errorf("expected only the leading @here to be a mention, got %d mentions", len(mentions))
+	}
+
+	if id := args.Get(1).AsUserMention(); id != 1 {
+		t.Errorf("expected the user mention 1, got %v", id)
+	}
+	if id := args.Get(0).AsUserMention(); !id.IsZero() {
+		t.Errorf("expected no user mention, got %v", id)
+	}
+}
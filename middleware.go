@@ -1,5 +1,21 @@
 package cmdlr2
 
-type Middleware struct {
-	Trigger func(ctx Ctx)
+// Middleware wraps the execution of a command. It receives the context of the current invocation and the next
+// handler of the chain. A middleware may modify the context before calling next, wrap the call or stop the execution
+// by simply not calling next at all.
+//
+// Middlewares are executed in the following order: router middlewares, command middlewares, sub command middlewares
+// and finally the handler of the resolved command. Within one level they run in the order they were registered.
+type Middleware func(ctx *Ctx, next ExecutionHandler)
+
+// chainMiddlewares wraps the given handler into the given middlewares so that the first middleware is the outermost one
+func chainMiddlewares(handler ExecutionHandler, middlewares []Middleware) ExecutionHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		middleware := middlewares[i]
+		next := handler
+		handler = func(ctx *Ctx) {
+			middleware(ctx, next)
+		}
+	}
+	return handler
 }
@@ -0,0 +1,418 @@
+package cmdlr2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+// ErrNoPages is returned by Paginator.Send if there is nothing to show
+var ErrNoPages = errors.New("the paginator has no pages")
+
+// PageControl is a reaction or button of a Paginator
+type PageControl int
+
+const (
+	PageFirst PageControl = iota
+	PagePrevious
+	PageNext
+	PageLast
+	// PageJump asks the user for the number of the page to show
+	PageJump
+	// PageClose deletes the message
+	PageClose
+)
+
+// DefaultPageControls are the controls used if a Paginator doesn't declare its own
+var DefaultPageControls = []PageControl{PageFirst, PagePrevious, PageNext, PageLast, PageJump, PageClose}
+
+var pageControlEmojis = map[PageControl]string{
+	PageFirst:    "⏮",
+	PagePrevious: "⬅",
+	PageNext:     "➡",
+	PageLast:     "⏭",
+	PageJump:     "🔢",
+	PageClose:    "❌",
+}
+
+// pageButtonPrefix prefixes the custom IDs of the paginator buttons
+const pageButtonPrefix = "cmdlr2:page:"
+
+const defaultPaginatorTimeout = 5 * time.Minute
+
+// Paginator shows one of several embeds at a time. Only the user who invoked the command can switch pages, once
+// nobody did for the duration of Timeout, the controls are removed.
+type Paginator struct {
+	// Pages are the embeds to show
+	Pages []*disgord.Embed
+	// PageFunc renders the page with the given index starting at 0. It is used instead of Pages if it is set and needs
+	// PageCount to be set as well.
+	PageFunc  func(ctx context.Context, page int) (*disgord.Embed, error)
+	PageCount int
+	// Controls are the reactions or buttons shown in this order, DefaultPageControls is used if it is empty
+	Controls []PageControl
+	// Timeout is the inactivity after which the controls are removed, it defaults to 5 minutes
+	Timeout time.Duration
+	// Buttons shows buttons instead of reactions if the transport implements ComponentResponder like the
+	// DisgordInteractionTransport. The DisgordTransport falls back to reactions.
+	Buttons bool
+}
+
+type paginatorSession struct {
+	paginator *Paginator
+	// command is the context of the command which sent the paginator, panics of the PageFunc are reported with it
+	command   *Ctx
+	router    *Router
+	transport Transport
+	controls  []PageControl
+	count     int
+	channelID disgord.Snowflake
+	// messageID may only be read once sent is closed
+	messageID disgord.Snowflake
+	sent      chan struct{}
+	userID    disgord.Snowflake
+	// components is set if the controls are buttons
+	components ComponentResponder
+
+	mutex      sync.Mutex
+	page       int
+	cancelJump func()
+	unregister func()
+	activity   chan struct{}
+	done       chan struct{}
+	stopOnce   sync.Once
+}
+
+func (p *Paginator) pageCount() int {
+	if p.PageFunc != nil {
+		return p.PageCount
+	}
+	return len(p.Pages)
+}
+
+func (p *Paginator) render(ctx context.Context, page int) (*disgord.Embed, error) {
+	if p.PageFunc != nil {
+		return p.PageFunc(ctx, page)
+	}
+	return p.Pages[page], nil
+}
+
+// Send sends the first page into the channel of the given context and adds the controls. It returns once the message
+// is sent, the controls keep working until the timeout or the shutdown of the router.
+func (p *Paginator) Send(ctx *Ctx) (*disgord.Message, error) {
+	count := p.pageCount()
+	if count <= 0 {
+		return nil, ErrNoPages
+	}
+
+	embed, err := p.render(ctx.context(), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &paginatorSession{
+		paginator: p,
+		command:   ctx,
+		router:    ctx.Router,
+		transport: ctx.Router.Transport,
+		controls:  p.Controls,
+		count:     count,
+		channelID: ctx.Event.Message.ChannelID,
+		userID:    ctx.Event.Message.Author.ID,
+		sent:      make(chan struct{}),
+		activity:  make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	if len(s.controls) == 0 {
+		s.controls = DefaultPageControls
+	}
+	if components, ok := s.transport.(ComponentResponder); ok && p.Buttons {
+		s.components = components
+	}
+
+	// The handlers are registered before sending so no click gets lost, they wait for the message ID
+	if s.components != nil {
+		s.unregister = s.router.RegisterComponentHandler(s.handleClick)
+	} else {
+		s.unregister = s.router.RegisterReactionHandler(s.handleReaction)
+	}
+
+	params := &disgord.CreateMessageParams{Embed: embed}
+	var message *disgord.Message
+	if s.components != nil {
+		message, err = s.components.SendMessageWithButtons(ctx.context(), s.channelID, params, s.buttons())
+	} else {
+		message, err = ctx.Responder.SendMessage(ctx.context(), s.channelID, params)
+	}
+	if err != nil {
+		close(s.sent)
+		s.unregister()
+		return nil, err
+	}
+	s.messageID = message.ID
+	close(s.sent)
+
+	if s.components == nil {
+		for _, control := range s.controls {
+			_ = s.transport.AddReaction(ctx.context(), s.channelID, s.messageID, pageControlEmojis[control])
+		}
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultPaginatorTimeout
+	}
+	go s.watch(s.router.lifetimeContext(), timeout)
+	return message, nil
+}
+
+func (s *paginatorSession) buttons() []*Button {
+	buttons := make([]*Button, len(s.controls))
+	for index, control := range s.controls {
+		style := ButtonSecondary
+		if control == PageClose {
+			style = ButtonDanger
+		}
+		buttons[index] = &Button{
+			CustomID: pageButtonPrefix + strconv.Itoa(int(control)),
+			Emoji:    &disgord.Emoji{Name: pageControlEmojis[control]},
+			Style:    style,
+		}
+	}
+	return buttons
+}
+
+// watch removes the controls after the given inactivity and stops the session once the router shuts down
+func (s *paginatorSession) watch(lifetime context.Context, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.activity:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(timeout)
+		case <-timer.C:
+			s.stop()
+			s.removeControls(lifetime)
+			return
+		case <-s.done:
+			return
+		case <-lifetime.Done():
+			s.stop()
+			return
+		}
+	}
+}
+
+// touch resets the inactivity timeout
+func (s *paginatorSession) touch() {
+	select {
+	case s.activity <- struct{}{}:
+	default:
+	}
+}
+
+// stop unregisters the handlers of the session
+func (s *paginatorSession) stop() {
+	s.stopOnce.Do(func() {
+		s.unregister()
+
+		s.mutex.Lock()
+		cancelJump := s.cancelJump
+		s.cancelJump = nil
+		s.mutex.Unlock()
+		if cancelJump != nil {
+			cancelJump()
+		}
+
+		close(s.done)
+	})
+}
+
+func (s *paginatorSession) removeControls(ctx context.Context) {
+	if s.components != nil {
+		_, _ = s.transport.EditMessage(ctx, s.channelID, s.messageID, &MessageEdit{Buttons: []*Button{}})
+		return
+	}
+
+	bot, err := s.router.CurrentUser()
+	if err != nil {
+		return
+	}
+	for _, control := range s.controls {
+		_ = s.transport.RemoveReaction(ctx, s.channelID, s.messageID, pageControlEmojis[control], bot.ID)
+	}
+}
+
+func (s *paginatorSession) control(emoji string) (PageControl, bool) {
+	for _, control := range s.controls {
+		if pageControlEmojis[control] == emoji {
+			return control, true
+		}
+	}
+	return 0, false
+}
+
+// isMessage waits until the message of the session is sent and checks whether it has the given ID
+func (s *paginatorSession) isMessage(messageID disgord.Snowflake) bool {
+	<-s.sent
+	return !s.messageID.IsZero() && messageID == s.messageID
+}
+
+func (s *paginatorSession) handleReaction(event *disgord.MessageReactionAdd) {
+	defer s.command.recoverPanic()
+	if event.PartialEmoji == nil || !s.isMessage(event.MessageID) {
+		return
+	}
+	bot, err := s.router.CurrentUser()
+	if err != nil || event.UserID == bot.ID {
+		return
+	}
+
+	ctx := s.router.lifetimeContext()
+	emoji := event.PartialEmoji.Name
+	_ = s.transport.RemoveReaction(ctx, s.channelID, s.messageID, emoji, event.UserID)
+
+	control, ok := s.control(emoji)
+	if !ok || event.UserID != s.userID {
+		return
+	}
+	s.touch()
+
+	if control == PageClose {
+		s.stop()
+		_ = s.transport.DeleteMessage(ctx, s.channelID, s.messageID)
+		return
+	}
+	if edit := s.handle(ctx, control); edit != nil {
+		_, _ = s.transport.EditMessage(ctx, s.channelID, s.messageID, edit)
+	}
+}
+
+func (s *paginatorSession) handleClick(interaction *Interaction) {
+	defer s.command.recoverPanic()
+	if !s.isMessage(interaction.Message.ID) {
+		return
+	}
+
+	ctx := s.router.lifetimeContext()
+	var control PageControl
+	var ok bool
+	if id := strings.TrimPrefix(interaction.Data.CustomID, pageButtonPrefix); id != interaction.Data.CustomID {
+		value, err := strconv.Atoi(id)
+		control, ok = PageControl(value), err == nil
+	}
+	author := interaction.Author()
+	if !ok || author == nil || author.ID != s.userID {
+		_ = s.components.UpdateComponentMessage(ctx, interaction, nil)
+		return
+	}
+	s.touch()
+
+	if control == PageClose {
+		s.stop()
+		_ = s.components.UpdateComponentMessage(ctx, interaction, nil)
+		_ = s.transport.DeleteMessage(ctx, s.channelID, s.messageID)
+		return
+	}
+	_ = s.components.UpdateComponentMessage(ctx, interaction, s.handle(ctx, control))
+}
+
+// handle applies the given control and returns the changes of the message, nil if the page didn't change
+func (s *paginatorSession) handle(ctx context.Context, control PageControl) *MessageEdit {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	page := s.page
+	switch control {
+	case PageFirst:
+		page = 0
+	case PagePrevious:
+		page--
+	case PageNext:
+		page++
+	case PageLast:
+		page = s.count - 1
+	case PageJump:
+		s.askForPage(ctx)
+		return nil
+	}
+	return s.show(ctx, page)
+}
+
+// show renders the given page if it differs from the current one, the lock has to be held
+func (s *paginatorSession) show(ctx context.Context, page int) *MessageEdit {
+	if page < 0 {
+		page = 0
+	}
+	if page >= s.count {
+		page = s.count - 1
+	}
+	if page == s.page {
+		return nil
+	}
+
+	embed, err := s.paginator.render(ctx, page)
+	if err != nil {
+		return nil
+	}
+	s.page = page
+	return &MessageEdit{Embed: embed}
+}
+
+// askForPage asks the user for the page to jump to and waits for the answer, the lock has to be held
+func (s *paginatorSession) askForPage(ctx context.Context) {
+	if s.cancelJump != nil {
+		return
+	}
+
+	question, err := s.transport.SendMessage(ctx, s.channelID, &disgord.CreateMessageParams{
+		Content: fmt.Sprintf("<@%v> Which page do you want to see? (1 - %d)", s.userID, s.count),
+	})
+	if err != nil {
+		return
+	}
+
+	cancelWait := s.router.awaitMessage(s.channelID, s.userID, func(answer *disgord.Message) {
+		s.jump(question, answer)
+	})
+	s.cancelJump = func() {
+		cancelWait()
+		_ = s.transport.DeleteMessage(s.router.lifetimeContext(), s.channelID, question.ID)
+	}
+}
+
+// jump shows the page the user answered with
+func (s *paginatorSession) jump(question, answer *disgord.Message) {
+	defer s.command.recoverPanic()
+	ctx := s.router.lifetimeContext()
+	_ = s.transport.DeleteMessage(ctx, s.channelID, question.ID)
+	_ = s.transport.DeleteMessage(ctx, s.channelID, answer.ID)
+	s.touch()
+
+	if edit := s.showAnswer(ctx, answer.Content); edit != nil {
+		_, _ = s.transport.EditMessage(ctx, s.channelID, s.messageID, edit)
+	}
+}
+
+// showAnswer shows the page the given answer names and returns the changes of the message
+func (s *paginatorSession) showAnswer(ctx context.Context, answer string) *MessageEdit {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cancelJump = nil
+	page, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil {
+		return nil
+	}
+	return s.show(ctx, page-1)
+}
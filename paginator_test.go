@@ -0,0 +1,152 @@
+package cmdlr2_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+	"github.com/zackartz/cmdlr2/cmdlrtest"
+)
+
+func paginatorHarness(paginator *cmdlr2.Paginator) *cmdlrtest.Harness {
+	h := newHarness()
+	for i := 1; i <= 5; i++ {
+		paginator.Pages = append(paginator.Pages, &disgord.Embed{Title: fmt.Sprintf("Page %d", i)})
+	}
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name: "pages",
+		HandlerE: func(ctx *cmdlr2.Ctx) error {
+			_, err := paginator.Send(ctx)
+			return err
+		},
+	})
+	return h
+}
+
+func assertPage(t *testing.T, h *cmdlrtest.Harness, messageID disgord.Snowflake, expected string) {
+	t.Helper()
+	if title := h.Transport.Message(messageID).Embeds[0].Title; title != expected {
+		t.Errorf("expected %q, got %q", expected, title)
+	}
+}
+
+func TestPaginatorReactions(t *testing.T) {
+	h := paginatorHarness(&cmdlr2.Paginator{Timeout: 50 * time.Millisecond})
+	message := h.Send("!pages")[0]
+	if reactions := strings.Join(h.Transport.Reactions(message.ID), ""); reactions != "⏮⬅➡⏭🔢❌" {
+		t.Errorf("unexpected reactions %q", reactions)
+	}
+
+	h.React(message.ID, "⏭")
+	assertPage(t, h, message.ID, "Page 5")
+	h.React(message.ID, "⬅")
+	assertPage(t, h, message.ID, "Page 4")
+	h.React(message.ID, "⏮")
+	assertPage(t, h, message.ID, "Page 1")
+
+	h.ReactAs(&disgord.User{ID: 1}, message.ID, "➡")
+	assertPage(t, h, message.ID, "Page 1")
+
+	h.React(message.ID, "🔢")
+	if replies := h.Send("3"); len(replies) != 0 {
+		t.Errorf("expected the answer not to be handled as a command, got %v", replies)
+	}
+	assertPage(t, h, message.ID, "Page 3")
+
+	time.Sleep(100 * time.Millisecond)
+	if reactions := h.Transport.Reactions(message.ID); len(reactions) != 0 {
+		t.Errorf("expected the reactions to be removed after the timeout, got %v", reactions)
+	}
+	h.React(message.ID, "➡")
+	assertPage(t, h, message.ID, "Page 3")
+}
+
+func TestPaginatorButtons(t *testing.T) {
+	h := paginatorHarness(&cmdlr2.Paginator{
+		Buttons:  true,
+		Controls: []cmdlr2.PageControl{cmdlr2.PagePrevious, cmdlr2.PageNext, cmdlr2.PageClose},
+	})
+	message := h.Send("!pages")[0]
+	buttons := h.Transport.Buttons(message.ID)
+	if len(buttons) != 3 || len(h.Transport.Reactions(message.ID)) != 0 {
+		t.Fatalf("expected 3 buttons and no reactions, got %v", buttons)
+	}
+
+	h.Click(message.ID, buttons[1].CustomID)
+	assertPage(t, h, message.ID, "Page 2")
+	h.ClickAs(&disgord.User{ID: 1}, message.ID, buttons[1].CustomID)
+	assertPage(t, h, message.ID, "Page 2")
+	h.Click(message.ID, buttons[0].CustomID)
+	assertPage(t, h, message.ID, "Page 1")
+
+	h.Click(message.ID, buttons[2].CustomID)
+	if h.Transport.Message(message.ID) != nil {
+		t.Errorf("expected the message to be deleted")
+	}
+}
+
+// earlyClickTransport clicks the third button of a message before returning it from SendMessageWithButtons
+type earlyClickTransport struct {
+	*cmdlr2.MemoryTransport
+	h       *cmdlrtest.Harness
+	clicked chan struct{}
+}
+
+func (t *earlyClickTransport) SendMessageWithButtons(ctx context.Context, channelID disgord.Snowflake, params *disgord.CreateMessageParams, buttons []*cmdlr2.Button) (*disgord.Message, error) {
+	message, err := t.MemoryTransport.SendMessageWithButtons(ctx, channelID, params, buttons)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		t.h.Click(message.ID, buttons[2].CustomID)
+		close(t.clicked)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	return message, nil
+}
+
+func TestPaginatorEarlyClick(t *testing.T) {
+	h := paginatorHarness(&cmdlr2.Paginator{Buttons: true})
+	transport := &earlyClickTransport{MemoryTransport: h.Transport, h: h, clicked: make(chan struct{})}
+	h.Router.Transport = transport
+
+	message := h.Send("!pages")[0]
+	<-transport.clicked
+	assertPage(t, h, message.ID, "Page 2")
+}
+
+func TestPaginatorPanic(t *testing.T) {
+	h := paginatorHarness(&cmdlr2.Paginator{
+		Buttons:   true,
+		PageCount: 3,
+		PageFunc: func(ctx context.Context, page int) (*disgord.Embed, error) {
+			if page == 1 {
+				panic("broken page")
+			}
+			return &disgord.Embed{Title: fmt.Sprintf("Page %d", page+1)}, nil
+		},
+	})
+	var handled error
+	h.Router.ErrorHandler = func(ctx *cmdlr2.Ctx, err error) {
+		if ctx.Command != nil && ctx.Command.Name == "pages" {
+			handled = err
+		}
+	}
+
+	message := h.Send("!pages")[0]
+	buttons := h.Transport.Buttons(message.ID)
+	h.Click(message.ID, buttons[2].CustomID)
+	var panicError *cmdlr2.PanicError
+	if !errors.As(handled, &panicError) || panicError.Value != "broken page" {
+		t.Errorf("expected the panic to be reported for the command, got %v", handled)
+	}
+	assertPage(t, h, message.ID, "Page 1")
+
+	h.Click(message.ID, buttons[3].CustomID)
+	assertPage(t, h, message.ID, "Page 3")
+}
@@ -0,0 +1,372 @@
+package cmdlr2
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andersfylling/disgord"
+)
+
+// ParamType names the type of a command parameter
+type ParamType string
+
+const (
+	ParamString   ParamType = "string"
+	ParamInt      ParamType = "int"
+	ParamBool     ParamType = "bool"
+	ParamDuration ParamType = "duration"
+	ParamUser     ParamType = "user"
+	ParamRole     ParamType = "role"
+	ParamChannel  ParamType = "channel"
+	// ParamRest consumes the rest of the line including all whitespace. It has to be the last parameter.
+	ParamRest ParamType = "rest"
+)
+
+// Param declares a single positional parameter of a command
+type Param struct {
+	Name string
+	// Type defaults to ParamString
+	Type        ParamType
+	Description string
+	Optional    bool
+	// Default is used if an optional parameter is missing
+	Default interface{}
+	// Min and Max limit the value of int parameters, the seconds of duration parameters and the length of
+	// string parameters
+	Min *float64
+	Max *float64
+	// Choices restricts the parameter to the given values, compared case-insensitively
+	Choices []string
+}
+
+func (p *Param) paramType() ParamType {
+	if p.Type == "" {
+		return ParamString
+	}
+	return p.Type
+}
+
+// Limit returns a pointer to the given value to be used as Param.Min or Param.Max
+func Limit(value float64) *float64 {
+	return &value
+}
+
+// ArgumentError is returned if the arguments of a message don't match the parameters or flags of the command
+type ArgumentError struct {
+	Param   *Param
+	Flag    *Flag
+	Raw     string
+	Message string
+}
+
+func (e *ArgumentError) Error() string {
+	switch {
+	case e.Param != nil:
+		return fmt.Sprintf("invalid argument `%s`: %s", e.Param.Name, e.Message)
+	case e.Flag != nil:
+		return fmt.Sprintf("invalid flag `--%s`: %s", e.Flag.Name, e.Message)
+	default:
+		return e.Message
+	}
+}
+
+type paramConverter struct {
+	display string
+	parse   func(ctx *Ctx, raw string) (interface{}, error)
+	// measure returns the number checked against Param.Min and Param.Max
+	measure func(value interface{}) float64
+	// complete returns the suggestions for the autocompletion of slash commands
+	complete func(ctx *Ctx, partial string) []string
+}
+
+// convert parses the given raw value. The message of the returned error defaults to `must be a <display>`, custom
+// types can return an *ArgumentError to use their own message.
+func (c *paramConverter) convert(ctx *Ctx, raw string) (interface{}, *ArgumentError) {
+	value, err := c.parse(ctx, raw)
+	if err != nil {
+		var argumentError *ArgumentError
+		if errors.As(err, &argumentError) {
+			return nil, &ArgumentError{Raw: raw, Message: argumentError.Message}
+		}
+		return nil, &ArgumentError{Raw: raw, Message: "must be a " + c.display}
+	}
+	return value, nil
+}
+
+var builtinParamTypes = map[ParamType]*paramConverter{
+	ParamString: {
+		display: "text",
+		parse: func(_ *Ctx, raw string) (interface{}, error) {
+			return raw, nil
+		},
+		measure: measureLength,
+	},
+	ParamRest: {
+		display: "text",
+		parse: func(_ *Ctx, raw string) (interface{}, error) {
+			return raw, nil
+		},
+		measure: measureLength,
+	},
+	ParamInt: {
+		display: "number",
+		parse: func(_ *Ctx, raw string) (interface{}, error) {
+			return (&Argument{raw: raw}).AsInt()
+		},
+		measure: func(value interface{}) float64 {
+			return float64(value.(int))
+		},
+	},
+	ParamBool: {
+		display: "boolean",
+		parse: func(_ *Ctx, raw string) (interface{}, error) {
+			return (&Argument{raw: raw}).AsBool()
+		},
+	},
+	ParamDuration: {
+		display: "duration",
+		parse: func(_ *Ctx, raw string) (interface{}, error) {
+			return (&Argument{raw: raw}).AsDuration()
+		},
+		measure: func(value interface{}) float64 {
+			return value.(time.Duration).Seconds()
+		},
+	},
+	ParamUser: {
+		display: "user",
+		parse: func(_ *Ctx, raw string) (interface{}, error) {
+			return parseMentionOrSnowflake(raw, (&Argument{raw: raw}).AsUserMentionID())
+		},
+	},
+	ParamRole: {
+		display: "role",
+		parse: func(_ *Ctx, raw string) (interface{}, error) {
+			return parseMentionOrSnowflake(raw, (&Argument{raw: raw}).AsRoleMentionID())
+		},
+	},
+	ParamChannel: {
+		display: "channel",
+		parse: func(_ *Ctx, raw string) (interface{}, error) {
+			return parseMentionOrSnowflake(raw, (&Argument{raw: raw}).AsChannelMentionID())
+		},
+	},
+}
+
+func measureLength(value interface{}) float64 {
+	return float64(utf8.RuneCountInString(value.(string)))
+}
+
+func parseMentionOrSnowflake(raw, mentionID string) (interface{}, error) {
+	if mentionID != "" {
+		raw = mentionID
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return disgord.Snowflake(id), nil
+}
+
+// ParamValues holds the parsed parameter values of a command invocation by name
+type ParamValues map[string]interface{}
+
+// Get returns the value of the given parameter and whether it was given or defaulted
+func (p ParamValues) Get(name string) (interface{}, bool) {
+	v, ok := p[name]
+	return v, ok
+}
+
+// Has checks whether the given parameter was given or defaulted
+func (p ParamValues) Has(name string) bool {
+	_, ok := p[name]
+	return ok
+}
+
+// String returns the value of the given string or rest parameter
+func (p ParamValues) String(name string) string {
+	v, _ := p[name].(string)
+	return v
+}
+
+// Int returns the value of the given int parameter
+func (p ParamValues) Int(name string) int {
+	v, _ := p[name].(int)
+	return v
+}
+
+// Bool returns the value of the given bool parameter
+func (p ParamValues) Bool(name string) bool {
+	v, _ := p[name].(bool)
+	return v
+}
+
+// Duration returns the value of the given duration parameter
+func (p ParamValues) Duration(name string) time.Duration {
+	v, _ := p[name].(time.Duration)
+	return v
+}
+
+// Snowflake returns the ID of the given user, role or channel parameter
+func (p ParamValues) Snowflake(name string) disgord.Snowflake {
+	v, _ := p[name].(disgord.Snowflake)
+	return v
+}
+
+// ParseParams parses the given arguments according to the given parameters. Only the built-in parameter types are
+// supported as the custom types are registered on the router.
+func ParseParams(params []*Param, args *Arguments) (ParamValues, error) {
+	return parseParams(nil, params, args)
+}
+
+func parseParams(ctx *Ctx, params []*Param, args *Arguments) (ParamValues, error) {
+	values := ParamValues{}
+
+	for index, param := range params {
+		converter, ok := ctx.paramConverter(param.paramType())
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter type %q", param.Type)
+		}
+
+		raw := args.Get(index).Raw()
+		if param.paramType() == ParamRest {
+			raw = args.rawFrom(index)
+		}
+
+		if index >= args.Amount() {
+			if !param.Optional {
+				return nil, &ArgumentError{Param: param, Message: "is missing"}
+			}
+			if param.Default != nil {
+				values[param.Name] = param.Default
+			}
+			continue
+		}
+
+		value, err := parseParam(ctx, param, converter, raw)
+		if err != nil {
+			return nil, err
+		}
+		values[param.Name] = value
+	}
+
+	if len(params) > 0 && params[len(params)-1].Type != ParamRest {
+		if args.Amount() > len(params) {
+			return nil, &ArgumentError{Raw: args.Get(len(params)).Raw(), Message: "too many arguments"}
+		}
+	}
+
+	return values, nil
+}
+
+func parseParam(ctx *Ctx, param *Param, converter *paramConverter, raw string) (interface{}, error) {
+	if len(param.Choices) > 0 {
+		choice := ""
+		for _, c := range param.Choices {
+			if Equals(c, raw, true) {
+				choice = c
+				break
+			}
+		}
+		if choice == "" {
+			return nil, &ArgumentError{Param: param, Raw: raw, Message: "must be one of `" + strings.Join(param.Choices, "`, `") + "`"}
+		}
+		raw = choice
+	}
+
+	value, err := converter.convert(ctx, raw)
+	if err != nil {
+		err.Param = param
+		return nil, err
+	}
+
+	if converter.measure != nil {
+		measure := converter.measure(value)
+		if param.Min != nil && measure < *param.Min {
+			return nil, &ArgumentError{Param: param, Raw: raw, Message: "must be at least " + formatLimit(*param.Min)}
+		}
+		if param.Max != nil && measure > *param.Max {
+			return nil, &ArgumentError{Param: param, Raw: raw, Message: "must be at most " + formatLimit(*param.Max)}
+		}
+	}
+
+	return value, nil
+}
+
+func formatLimit(limit float64) string {
+	return strconv.FormatFloat(limit, 'f', -1, 64)
+}
+
+// usage renders the usage token of the parameter like `<name>`, `[name]` or `<name...>`
+func (p *Param) usage() string {
+	name := p.Name
+	if len(p.Choices) > 0 {
+		name = strings.Join(p.Choices, "|")
+	}
+	if p.paramType() == ParamRest {
+		name += "..."
+	}
+	if p.Optional {
+		return "[" + name + "]"
+	}
+	return "<" + name + ">"
+}
+
+// UsageString returns the usage of the command. If no Usage is set, it is generated from the flags and parameters.
+func (c *Command) UsageString() string {
+	if c.Usage != "" || (len(c.Params) == 0 && len(c.Flags) == 0) {
+		return c.Usage
+	}
+
+	tokens := []string{c.Name}
+	if len(c.Flags) > 0 {
+		tokens = append(tokens, "[flags]")
+	}
+	for _, param := range c.Params {
+		tokens = append(tokens, param.usage())
+	}
+	return strings.Join(tokens, " ")
+}
+
+// withArguments wraps the given handler so that it only gets called if the arguments match the flags and parameters
+// of the command
+func (c *Command) withArguments(handler ExecutionHandler) ExecutionHandler {
+	return func(ctx *Ctx) {
+		if ctx.Interaction != nil {
+			if err := c.parseInteractionArguments(ctx); err != nil {
+				ctx.handleError(err)
+				return
+			}
+			handler(ctx)
+			return
+		}
+
+		if len(c.Flags) > 0 {
+			flags, args, err := parseFlags(ctx, c.Flags, ctx.Args)
+			if err != nil {
+				ctx.handleError(err)
+				return
+			}
+			ctx.Flags = flags
+			ctx.Args = args
+		}
+
+		if len(c.Params) > 0 {
+			values, err := parseParams(ctx, c.Params, ctx.Args)
+			if err != nil {
+				ctx.handleError(err)
+				return
+			}
+			ctx.Params = values
+		}
+
+		handler(ctx)
+	}
+}
+
+func argumentErrorText(ctx *Ctx, err *ArgumentError) string {
+	return fmt.Sprintf("Invalid usage: %s.\nUsage: `%s%s`", err, ctx.Prefix, ctx.Command.UsageString())
+}
@@ -0,0 +1,97 @@
+package cmdlr2_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+)
+
+func TestParseParams(t *testing.T) {
+	params := []*cmdlr2.Param{
+		{Name: "user", Type: cmdlr2.ParamUser},
+		{Name: "days", Type: cmdlr2.ParamInt, Optional: true, Default: 1, Min: cmdlr2.Limit(1), Max: cmdlr2.Limit(7)},
+		{Name: "reason", Type: cmdlr2.ParamRest, Optional: true},
+	}
+
+	values, err := cmdlr2.ParseParams(params, cmdlr2.ParseArguments(`<@!123> 3 being  "rude"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values.Snowflake("user") != disgord.Snowflake(123) || values.Int("days") != 3 || values.String("reason") != `being  "rude"` {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	values, err = cmdlr2.ParseParams(params, cmdlr2.ParseArguments("123"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values.Int("days") != 1 || values.Has("reason") {
+		t.Errorf("expected the default days and no reason, got %v", values)
+	}
+
+	tests := map[string]string{
+		"":          "invalid argument `user`: is missing",
+		"abc":       "invalid argument `user`: must be a user",
+		"123 0":     "invalid argument `days`: must be at least 1",
+		"123 8":     "invalid argument `days`: must be at most 7",
+		"123 three": "invalid argument `days`: must be a number",
+	}
+	for input, expected := range tests {
+		_, err := cmdlr2.ParseParams(params, cmdlr2.ParseArguments(input))
+		var argumentError *cmdlr2.ArgumentError
+		if !errors.As(err, &argumentError) || err.Error() != expected {
+			t.Errorf("%q: expected %q, got %v", input, expected, err)
+		}
+	}
+}
+
+func TestParseParamsConstraints(t *testing.T) {
+	params := []*cmdlr2.Param{
+		{Name: "mode", Type: cmdlr2.ParamString, Choices: []string{"Fast", "Slow"}},
+		{Name: "delay", Type: cmdlr2.ParamDuration, Max: cmdlr2.Limit(60)},
+		{Name: "force", Type: cmdlr2.ParamBool, Optional: true},
+	}
+
+	values, err := cmdlr2.ParseParams(params, cmdlr2.ParseArguments("fast 30s true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values.String("mode") != "Fast" || values.Duration("delay") != 30*time.Second || !values.Bool("force") {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	tests := map[string]string{
+		"medium 1s":      "invalid argument `mode`: must be one of `Fast`, `Slow`",
+		"slow 2m":        "invalid argument `delay`: must be at most 60",
+		"slow 1s true x": "too many arguments",
+	}
+	for input, expected := range tests {
+		if _, err := cmdlr2.ParseParams(params, cmdlr2.ParseArguments(input)); err == nil || err.Error() != expected {
+			t.Errorf("%q: expected %q, got %v", input, expected, err)
+		}
+	}
+}
+
+func TestParamsUsageError(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name: "remind",
+		Params: []*cmdlr2.Param{
+			{Name: "in", Type: cmdlr2.ParamDuration},
+			{Name: "text", Type: cmdlr2.ParamRest},
+		},
+		Handler: func(ctx *cmdlr2.Ctx) {
+			_ = ctx.ResponseText(fmt.Sprintf("%v %s", ctx.Params.Duration("in"), ctx.Params.String("text")))
+		},
+	})
+
+	assertReplies(t, "!remind 5m feed the cat", send(h, "!remind 5m feed the cat"), "5m0s feed the cat")
+	assertReplies(t, "!remind soon", send(h, "!remind soon"),
+		"Invalid usage: invalid argument `in`: must be a duration.\nUsage: `!remind <in> <text...>`")
+	assertReplies(t, "?remind 5m", send(h, "?remind 5m"),
+		"Invalid usage: invalid argument `text`: is missing.\nUsage: `?remind <in> <text...>`")
+}
@@ -0,0 +1,217 @@
+package cmdlr2
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+// PermissionReason describes why the execution of a command was denied
+type PermissionReason int
+
+const (
+	DeniedGuildOnly PermissionReason = iota
+	DeniedDMOnly
+	DeniedOwnerOnly
+	DeniedRoles
+	DeniedMemberPermissions
+	DeniedBotPermissions
+)
+
+// PermissionError is passed to the error handler if the requirements of a command aren't met
+type PermissionError struct {
+	// Command is the command of the resolved path which declared the requirement
+	Command *Command
+	Reason  PermissionReason
+	// Missing holds the missing permissions for DeniedMemberPermissions and DeniedBotPermissions
+	Missing disgord.PermissionBit
+	// Err is set if the permissions couldn't be resolved
+	Err error
+}
+
+func (e *PermissionError) Error() string {
+	switch e.Reason {
+	case DeniedGuildOnly:
+		return "command can only be used in guilds"
+	case DeniedDMOnly:
+		return "command can only be used in direct messages"
+	case DeniedOwnerOnly:
+		return "command can only be used by the bot owners"
+	case DeniedRoles:
+		return "member has none of the allowed roles"
+	case DeniedMemberPermissions:
+		if e.Err != nil {
+			return "unable to resolve the member permissions: " + e.Err.Error()
+		}
+		return "member is missing the permissions " + PermissionNames(e.Missing)
+	default:
+		if e.Err != nil {
+			return "unable to resolve the bot permissions: " + e.Err.Error()
+		}
+		return "bot is missing the permissions " + PermissionNames(e.Missing)
+	}
+}
+
+var permissionNames = []struct {
+	bit  disgord.PermissionBit
+	name string
+}{
+	{disgord.PermissionCreateInstantInvite, "Create Invite"},
+	{disgord.PermissionKickMembers, "Kick Members"},
+	{disgord.PermissionBanMembers, "Ban Members"},
+	{disgord.PermissionAdministrator, "Administrator"},
+	{disgord.PermissionManageChannels, "Manage Channels"},
+	{disgord.PermissionManageServer, "Manage Server"},
+	{disgord.PermissionAddReactions, "Add Reactions"},
+	{disgord.PermissionViewAuditLogs, "View Audit Log"},
+	{disgord.PermissionVoicePrioritySpeaker, "Priority Speaker"},
+	{disgord.PermissionStream, "Video"},
+	{disgord.PermissionReadMessages, "Read Messages"},
+	{disgord.PermissionSendMessages, "Send Messages"},
+	{disgord.PermissionSendTTSMessages, "Send TTS Messages"},
+	{disgord.PermissionManageMessages, "Manage Messages"},
+	{disgord.PermissionEmbedLinks, "Embed Links"},
+	{disgord.PermissionAttachFiles, "Attach Files"},
+	{disgord.PermissionReadMessageHistory, "Read Message History"},
+	{disgord.PermissionMentionEveryone, "Mention Everyone"},
+	{disgord.PermissionUseExternalEmojis, "Use External Emojis"},
+	{disgord.PermissionViewGuildInsights, "View Server Insights"},
+	{disgord.PermissionVoiceConnect, "Connect"},
+	{disgord.PermissionVoiceSpeak, "Speak"},
+	{disgord.PermissionVoiceMuteMembers, "Mute Members"},
+	{disgord.PermissionVoiceDeafenMembers, "Deafen Members"},
+	{disgord.PermissionVoiceMoveMembers, "Move Members"},
+	{disgord.PermissionVoiceUseVAD, "Use Voice Activity"},
+	{disgord.PermissionChangeNickname, "Change Nickname"},
+	{disgord.PermissionManageNicknames, "Manage Nicknames"},
+	{disgord.PermissionManageRoles, "Manage Roles"},
+	{disgord.PermissionManageWebhooks, "Manage Webhooks"},
+	{disgord.PermissionManageEmojis, "Manage Emojis"},
+}
+
+// PermissionNames returns the human readable names of the given permissions
+func PermissionNames(permissions disgord.PermissionBit) string {
+	var names []string
+	for _, permission := range permissionNames {
+		if permissions.Contains(permission.bit) {
+			names = append(names, permission.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// checkRequirements checks the requirements of every command of the resolved path
+func (ctx *Ctx) checkRequirements() *PermissionError {
+	msg := ctx.Event.Message
+	inGuild := !msg.GuildID.IsZero()
+
+	for _, command := range ctx.path {
+		if command.GuildOnly && !inGuild {
+			return &PermissionError{Command: command, Reason: DeniedGuildOnly}
+		}
+		if command.DMOnly && inGuild {
+			return &PermissionError{Command: command, Reason: DeniedDMOnly}
+		}
+		if command.OwnerOnly && !ctx.Router.IsOwner(msg.Author.ID) {
+			return &PermissionError{Command: command, Reason: DeniedOwnerOnly}
+		}
+
+		if len(command.AllowedRoles) > 0 && !ctx.hasAllowedRole(command.AllowedRoles) {
+			return &PermissionError{Command: command, Reason: DeniedRoles}
+		}
+
+		if command.Permissions != 0 {
+			if !inGuild {
+				return &PermissionError{Command: command, Reason: DeniedGuildOnly}
+			}
+			missing, err := ctx.missingPermissions(msg.Author.ID, command.Permissions)
+			if err != nil || missing != 0 {
+				return &PermissionError{Command: command, Reason: DeniedMemberPermissions, Missing: missing, Err: err}
+			}
+		}
+
+		if command.BotPermissions != 0 && inGuild {
+			u, err := ctx.Router.CurrentUser()
+			var missing disgord.PermissionBit
+			if err == nil {
+				missing, err = ctx.missingPermissions(u.ID, command.BotPermissions)
+			}
+			if err != nil || missing != 0 {
+				return &PermissionError{Command: command, Reason: DeniedBotPermissions, Missing: missing, Err: err}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (ctx *Ctx) hasAllowedRole(allowedRoles []disgord.Snowflake) bool {
+	member := ctx.Event.Message.Member
+	if member == nil {
+		return false
+	}
+
+	for _, role := range member.Roles {
+		for _, allowedRole := range allowedRoles {
+			if role == allowedRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// missingPermissions returns the permissions of the given set the given user is missing in the current channel
+func (ctx *Ctx) missingPermissions(userID disgord.Snowflake, required disgord.PermissionBit) (disgord.PermissionBit, error) {
+	resolver, ok := ctx.Router.Transport.(PermissionResolver)
+	if !ok {
+		return required, fmt.Errorf("the transport can't resolve permissions")
+	}
+
+	msg := ctx.Event.Message
+	permissions, err := resolver.MemberPermissions(ctx.context(), msg.GuildID, msg.ChannelID, userID)
+	if err != nil {
+		return required, err
+	}
+
+	if permissions.Contains(disgord.PermissionAdministrator) {
+		return 0, nil
+	}
+	return required &^ permissions, nil
+}
+
+// IsOwner checks whether the given user is one of the bot owners
+func (r *Router) IsOwner(userID disgord.Snowflake) bool {
+	for _, owner := range r.Owners {
+		if owner == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionErrorText(err *PermissionError) string {
+	var text string
+	switch err.Reason {
+	case DeniedGuildOnly:
+		text = "This command can only be used in a server."
+	case DeniedDMOnly:
+		text = "This command can only be used in direct messages."
+	case DeniedOwnerOnly:
+		text = "This command can only be used by the bot owners."
+	case DeniedRoles:
+		text = "You don't have a role which is allowed to use this command."
+	case DeniedMemberPermissions:
+		text = "You are missing the following permissions: " + PermissionNames(err.Missing)
+		if err.Err != nil {
+			text = "Your permissions couldn't be checked, please try again later."
+		}
+	case DeniedBotPermissions:
+		text = "I am missing the following permissions: " + PermissionNames(err.Missing)
+		if err.Err != nil {
+			text = "My permissions couldn't be checked, please try again later."
+		}
+	}
+	return text
+}
@@ -0,0 +1,99 @@
+package cmdlr2_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+)
+
+func TestMemberPermissions(t *testing.T) {
+	h := newHarness()
+	ban := echoCommand("ban")
+	ban.Permissions = disgord.PermissionBanMembers | disgord.PermissionKickMembers
+	ban.SubCommands = []*cmdlr2.Command{echoCommand("list")}
+	h.Router.RegisterCMD(ban)
+	h.Transport.SetPermissions(h.Bot.ID, disgord.PermissionSendMessages)
+
+	assertReplies(t, "!ban", send(h, "!ban"), "You are missing the following permissions: Kick Members, Ban Members")
+	assertReplies(t, "!ban list", send(h, "!ban list"), "You are missing the following permissions: Kick Members, Ban Members")
+
+	h.Transport.SetPermissions(h.User.ID, disgord.PermissionBanMembers|disgord.PermissionKickMembers)
+	assertReplies(t, "!ban x", send(h, "!ban x"), "ban:x")
+
+	h.Transport.SetPermissions(h.User.ID, disgord.PermissionAdministrator)
+	assertReplies(t, "!ban list", send(h, "!ban list"), "list:")
+
+	h.GuildID = 0
+	assertReplies(t, "!ban in DM", send(h, "!ban"), "This command can only be used in a server.")
+}
+
+func TestBotPermissions(t *testing.T) {
+	h := newHarness()
+	purge := echoCommand("purge")
+	purge.BotPermissions = disgord.PermissionManageMessages
+	h.Router.RegisterCMD(purge)
+
+	assertReplies(t, "!purge", send(h, "!purge"), "I am missing the following permissions: Manage Messages")
+
+	h.Transport.SetPermissions(h.Bot.ID, disgord.PermissionManageMessages)
+	assertReplies(t, "!purge", send(h, "!purge"), "purge:")
+}
+
+func TestChannelRequirements(t *testing.T) {
+	h := newHarness()
+	server := echoCommand("server")
+	server.GuildOnly = true
+	private := echoCommand("private")
+	private.DMOnly = true
+	owner := echoCommand("shutdown")
+	owner.OwnerOnly = true
+	h.Router.RegisterCMDList([]*cmdlr2.Command{server, private, owner})
+
+	assertReplies(t, "!server", send(h, "!server"), "server:")
+	assertReplies(t, "!private", send(h, "!private"), "This command can only be used in direct messages.")
+	assertReplies(t, "!shutdown", send(h, "!shutdown"), "This command can only be used by the bot owners.")
+
+	h.GuildID = 0
+	h.Router.Owners = []disgord.Snowflake{h.User.ID}
+	assertReplies(t, "!server", send(h, "!server"), "This command can only be used in a server.")
+	assertReplies(t, "!private", send(h, "!private"), "private:")
+	assertReplies(t, "!shutdown", send(h, "!shutdown"), "shutdown:")
+}
+
+func TestAllowedRoles(t *testing.T) {
+	h := newHarness()
+	mod := echoCommand("mod")
+	mod.AllowedRoles = []disgord.Snowflake{10, 11}
+	mod.SubCommands = []*cmdlr2.Command{echoCommand("warn")}
+	h.Router.RegisterCMD(mod)
+
+	var denied error
+	h.Router.ErrorHandler = func(ctx *cmdlr2.Ctx, err error) {
+		denied = err
+	}
+
+	sendWithRoles := func(content string, roles ...disgord.Snowflake) []string {
+		var replies []string
+		for _, message := range h.SendMessage(&disgord.Message{
+			ID:        h.Transport.NextID(),
+			ChannelID: h.ChannelID,
+			GuildID:   h.GuildID,
+			Author:    h.User,
+			Member:    &disgord.Member{GuildID: h.GuildID, UserID: h.User.ID, User: h.User, Roles: roles},
+			Content:   content,
+		}) {
+			replies = append(replies, message.Content)
+		}
+		return replies
+	}
+
+	assertReplies(t, "!mod warn", sendWithRoles("!mod warn", 12))
+	var permissionError *cmdlr2.PermissionError
+	if !errors.As(denied, &permissionError) || permissionError.Reason != cmdlr2.DeniedRoles || permissionError.Command != mod {
+		t.Errorf("expected the sub command to inherit the roles of its parent, got %v", denied)
+	}
+
+	assertReplies(t, "!mod warn", sendWithRoles("!mod warn", 12, 11), "warn:")
+}
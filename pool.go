@@ -0,0 +1,186 @@
+package cmdlr2
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/andersfylling/disgord"
+)
+
+// QueueFullPolicy decides what happens to commands arriving while the queue of the worker pool is full
+type QueueFullPolicy int
+
+const (
+	// QueueDrop rejects the command by passing ErrQueueFull to the error handler
+	QueueDrop QueueFullPolicy = iota
+	// QueueBlock blocks the event handler of the transport until there is space in the queue again
+	QueueBlock
+)
+
+// ErrQueueFull is passed to the error handler if a command is dropped because the queue of the worker pool is full
+var ErrQueueFull = errors.New("the command queue is full")
+
+// ErrShuttingDown is passed to the error handler if a command waiting for space in the queue is dropped because the
+// router shuts down
+var ErrShuttingDown = errors.New("the router is shutting down")
+
+// workerPool executes queued jobs using a fixed amount of goroutines. Every key, usually a guild, has its own queue and
+// the queues take turns so a single key can't starve the others.
+type workerPool struct {
+	mutex sync.Mutex
+	// cond is broadcast whenever a job is queued or taken and once the pool is closed
+	cond   *sync.Cond
+	queues map[disgord.Snowflake][]func()
+	// order holds the keys with queued jobs in the order they get their next turn
+	order     []disgord.Snowflake
+	depth     int
+	size      int
+	guildSize int
+	closed    bool
+}
+
+func newWorkerPool(workers, size, guildSize int) *workerPool {
+	if size <= 0 {
+		size = workers
+	}
+
+	pool := &workerPool{
+		queues:    map[disgord.Snowflake][]func(){},
+		size:      size,
+		guildSize: guildSize,
+	}
+	pool.cond = sync.NewCond(&pool.mutex)
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+func (p *workerPool) full(key disgord.Snowflake) bool {
+	return p.depth >= p.size || (p.guildSize > 0 && len(p.queues[key]) >= p.guildSize)
+}
+
+// submit queues the given job. If the queue is full, it waits for space if block is set and returns ErrQueueFull
+// otherwise. Once the pool is closed, it returns ErrShuttingDown.
+func (p *workerPool) submit(key disgord.Snowflake, job func(), block bool) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for p.full(key) && block && !p.closed {
+		p.cond.Wait()
+	}
+	if p.closed {
+		return ErrShuttingDown
+	}
+	if p.full(key) {
+		return ErrQueueFull
+	}
+
+	if len(p.queues[key]) == 0 {
+		p.order = append(p.order, key)
+	}
+	p.queues[key] = append(p.queues[key], job)
+	p.depth++
+	p.cond.Broadcast()
+	return nil
+}
+
+// take returns the next job, it waits for one if the queue is empty. Once the pool is closed and the queue is drained,
+// it returns nil.
+func (p *workerPool) take() func() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for p.depth == 0 && !p.closed {
+		p.cond.Wait()
+	}
+	if p.depth == 0 {
+		return nil
+	}
+
+	key := p.order[0]
+	p.order = p.order[1:]
+	queue := p.queues[key]
+	job := queue[0]
+	if len(queue) > 1 {
+		p.queues[key] = queue[1:]
+		p.order = append(p.order, key)
+	} else {
+		delete(p.queues, key)
+	}
+	p.depth--
+	p.cond.Broadcast()
+	return job
+}
+
+func (p *workerPool) work() {
+	for job := p.take(); job != nil; job = p.take() {
+		job()
+	}
+}
+
+// close stops the workers once the queue is drained
+func (p *workerPool) close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closed = true
+	p.cond.Broadcast()
+}
+
+func (p *workerPool) queueDepth() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.depth
+}
+
+// QueueDepth returns the amount of commands waiting for a worker
+func (r *Router) QueueDepth() int {
+	r.mutex.RLock()
+	pool := r.pool
+	r.mutex.RUnlock()
+
+	if pool == nil {
+		return 0
+	}
+	return pool.queueDepth()
+}
+
+// execute runs the given execution on the worker pool if it is enabled, otherwise on the current goroutine. Nothing is
+// executed once the router is shut down.
+func (r *Router) execute(ctx *Ctx, execution func()) {
+	if !r.begin() {
+		return
+	}
+
+	r.mutex.RLock()
+	pool := r.pool
+	r.mutex.RUnlock()
+	if pool == nil {
+		defer r.running.Done()
+		execution()
+		return
+	}
+
+	job := func() {
+		defer r.running.Done()
+		// Skip executions which were cancelled while waiting in the queue
+		if ctx.context().Err() != nil {
+			return
+		}
+		execution()
+	}
+	if err := pool.submit(ctx.queueKey(), job, r.QueueFullPolicy == QueueBlock); err != nil {
+		defer r.running.Done()
+		ctx.handleError(err)
+	}
+}
+
+// queueKey returns the key the execution is queued under, the guild or the channel for direct messages
+func (ctx *Ctx) queueKey() disgord.Snowflake {
+	if msg := ctx.Event.Message; !msg.GuildID.IsZero() {
+		return msg.GuildID
+	}
+	return ctx.Event.Message.ChannelID
+}
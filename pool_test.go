@@ -0,0 +1,124 @@
+package cmdlr2_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+	"github.com/zackartz/cmdlr2/cmdlrtest"
+)
+
+func newPoolHarness(queueSize int) (*cmdlrtest.Harness, chan struct{}, chan struct{}) {
+	h := cmdlrtest.New(cmdlr2.Create(&cmdlr2.Router{
+		Prefixes:  []string{"!"},
+		Workers:   1,
+		QueueSize: queueSize,
+	}))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name: "block",
+		Handler: func(ctx *cmdlr2.Ctx) {
+			started <- struct{}{}
+			<-release
+		},
+	})
+	return h, started, release
+}
+
+func TestQueueFull(t *testing.T) {
+	h, started, release := newPoolHarness(1)
+	h.Router.RegisterCMD(echoCommand("ping"))
+
+	h.Send("!block")
+	<-started
+	h.Send("!ping")
+	if depth := h.Router.QueueDepth(); depth != 1 {
+		t.Errorf("expected a queue depth of 1, got %d", depth)
+	}
+	assertReplies(t, "!ping", send(h, "!ping"), "I'm busy right now, please try again in a moment.")
+
+	close(release)
+	if err := h.Router.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if depth := h.Router.QueueDepth(); depth != 0 {
+		t.Errorf("expected an empty queue, got %d", depth)
+	}
+	if replies := h.Replies(); replies[len(replies)-1].Content != "ping:" {
+		t.Errorf("expected the queued command to be executed, got %q", replies[len(replies)-1].Content)
+	}
+}
+
+func TestQueueShutdown(t *testing.T) {
+	h, started, release := newPoolHarness(1)
+	h.Router.QueueFullPolicy = cmdlr2.QueueBlock
+	h.Router.RegisterCMD(echoCommand("ping"))
+	handled := make(chan error, 1)
+	h.Router.ErrorHandler = func(ctx *cmdlr2.Ctx, err error) {
+		handled <- err
+	}
+
+	h.Send("!block")
+	<-started
+	h.Send("!ping")
+	go h.Send("!ping")
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.Router.Shutdown(ctx); err == nil {
+		t.Error("expected the shutdown to time out")
+	}
+	if err := <-handled; !errors.Is(err, cmdlr2.ErrShuttingDown) {
+		t.Errorf("expected the waiting command to be dropped because of the shutdown, got %v", err)
+	}
+	close(release)
+}
+
+func TestQueueFairness(t *testing.T) {
+	h, started, release := newPoolHarness(10)
+
+	var mutex sync.Mutex
+	var order []string
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name: "record",
+		Handler: func(ctx *cmdlr2.Ctx) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			order = append(order, ctx.Args.Raw())
+		},
+	})
+
+	sendIn := func(guildID disgord.Snowflake, content string) {
+		h.SendMessage(&disgord.Message{
+			ID:        h.Transport.NextID(),
+			ChannelID: h.ChannelID,
+			GuildID:   guildID,
+			Author:    h.User,
+			Content:   content,
+		})
+	}
+
+	sendIn(1, "!block")
+	<-started
+	sendIn(1, "!record a1")
+	sendIn(1, "!record a2")
+	sendIn(1, "!record a3")
+	sendIn(2, "!record b1")
+	sendIn(2, "!record b2")
+
+	close(release)
+	if err := h.Router.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "a1,b1,a2,b2,a3" {
+		t.Errorf("expected the guilds to take turns, got %v", order)
+	}
+}
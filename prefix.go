@@ -0,0 +1,194 @@
+package cmdlr2
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/andersfylling/disgord"
+)
+
+// PrefixProvider resolves the prefixes valid for an incoming message
+type PrefixProvider interface {
+	Prefixes(event *disgord.MessageCreate) ([]string, error)
+}
+
+// MemoryPrefixProvider resolves prefixes from in-memory overrides. Channel overrides take precedence over guild
+// overrides which take precedence over the default prefixes.
+type MemoryPrefixProvider struct {
+	mutex    sync.RWMutex
+	defaults []string
+	guilds   map[disgord.Snowflake][]string
+	channels map[disgord.Snowflake][]string
+}
+
+var _ PrefixProvider = (*MemoryPrefixProvider)(nil)
+
+// NewMemoryPrefixProvider creates a new in-memory prefix provider using the given default prefixes
+func NewMemoryPrefixProvider(defaults ...string) *MemoryPrefixProvider {
+	return &MemoryPrefixProvider{
+		defaults: defaults,
+		guilds:   map[disgord.Snowflake][]string{},
+		channels: map[disgord.Snowflake][]string{},
+	}
+}
+
+func (p *MemoryPrefixProvider) Prefixes(event *disgord.MessageCreate) ([]string, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if prefixes, ok := p.channels[event.Message.ChannelID]; ok {
+		return prefixes, nil
+	}
+	if prefixes, ok := p.guilds[event.Message.GuildID]; ok && !event.Message.GuildID.IsZero() {
+		return prefixes, nil
+	}
+	return p.defaults, nil
+}
+
+// SetDefaultPrefixes sets the prefixes used if there is no override
+func (p *MemoryPrefixProvider) SetDefaultPrefixes(prefixes ...string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.defaults = prefixes
+}
+
+// SetGuildPrefixes overrides the prefixes of the given guild. Calling it without prefixes removes the override.
+func (p *MemoryPrefixProvider) SetGuildPrefixes(guildID disgord.Snowflake, prefixes ...string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	setPrefixOverride(p.guilds, guildID, prefixes)
+}
+
+// SetChannelPrefixes overrides the prefixes of the given channel. Calling it without prefixes removes the override.
+func (p *MemoryPrefixProvider) SetChannelPrefixes(channelID disgord.Snowflake, prefixes ...string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	setPrefixOverride(p.channels, channelID, prefixes)
+}
+
+func setPrefixOverride(overrides map[disgord.Snowflake][]string, id disgord.Snowflake, prefixes []string) {
+	if len(prefixes) == 0 {
+		delete(overrides, id)
+		return
+	}
+	overrides[id] = prefixes
+}
+
+type prefixFile struct {
+	Defaults []string            `json:"defaults"`
+	Guilds   map[string][]string `json:"guilds"`
+	Channels map[string][]string `json:"channels"`
+}
+
+// FilePrefixProvider is a MemoryPrefixProvider which persists all changes into a JSON file
+type FilePrefixProvider struct {
+	*MemoryPrefixProvider
+	path      string
+	saveMutex sync.Mutex
+}
+
+var _ PrefixProvider = (*FilePrefixProvider)(nil)
+
+// NewFilePrefixProvider loads the prefixes from the given file. If the file doesn't exist, the given default
+// prefixes are used and the file is created with the first change.
+func NewFilePrefixProvider(path string, defaults ...string) (*FilePrefixProvider, error) {
+	provider := &FilePrefixProvider{
+		MemoryPrefixProvider: NewMemoryPrefixProvider(defaults...),
+		path:                 path,
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return provider, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file prefixFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Defaults != nil {
+		provider.defaults = file.Defaults
+	}
+	for id, prefixes := range file.Guilds {
+		provider.guilds[disgord.ParseSnowflakeString(id)] = prefixes
+	}
+	for id, prefixes := range file.Channels {
+		provider.channels[disgord.ParseSnowflakeString(id)] = prefixes
+	}
+	return provider, nil
+}
+
+// SetDefaultPrefixes sets the prefixes used if there is no override and saves the file
+func (p *FilePrefixProvider) SetDefaultPrefixes(prefixes ...string) error {
+	p.MemoryPrefixProvider.SetDefaultPrefixes(prefixes...)
+	return p.save()
+}
+
+// SetGuildPrefixes overrides the prefixes of the given guild and saves the file
+func (p *FilePrefixProvider) SetGuildPrefixes(guildID disgord.Snowflake, prefixes ...string) error {
+	p.MemoryPrefixProvider.SetGuildPrefixes(guildID, prefixes...)
+	return p.save()
+}
+
+// SetChannelPrefixes overrides the prefixes of the given channel and saves the file
+func (p *FilePrefixProvider) SetChannelPrefixes(channelID disgord.Snowflake, prefixes ...string) error {
+	p.MemoryPrefixProvider.SetChannelPrefixes(channelID, prefixes...)
+	return p.save()
+}
+
+func (p *FilePrefixProvider) save() error {
+	p.saveMutex.Lock()
+	defer p.saveMutex.Unlock()
+
+	p.mutex.RLock()
+	file := prefixFile{
+		Defaults: p.defaults,
+		Guilds:   make(map[string][]string, len(p.guilds)),
+		Channels: make(map[string][]string, len(p.channels)),
+	}
+	for id, prefixes := range p.guilds {
+		file.Guilds[id.String()] = prefixes
+	}
+	for id, prefixes := range p.channels {
+		file.Channels[id.String()] = prefixes
+	}
+	p.mutex.RUnlock()
+
+	// Prefixes often contain characters like `>` which shouldn't be escaped
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(p.path, data.Bytes(), 0644)
+}
+
+// resolvePrefixes returns the prefixes valid for the given message
+func (r *Router) resolvePrefixes(event *disgord.MessageCreate) []string {
+	prefixes := r.Prefixes
+	if r.PrefixProvider != nil {
+		if provided, err := r.PrefixProvider.Prefixes(event); err == nil {
+			prefixes = provided
+		}
+	}
+
+	if r.MentionPrefix {
+		if u, err := r.CurrentUser(); err == nil {
+			mentions := []string{"<@" + u.ID.String() + ">", "<@!" + u.ID.String() + ">"}
+			prefixes = append(mentions, prefixes...)
+		}
+	}
+	return prefixes
+}
@@ -0,0 +1,81 @@
+package cmdlr2_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+)
+
+func TestMemoryPrefixProvider(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMD(echoCommand("ping"))
+	provider := cmdlr2.NewMemoryPrefixProvider("!")
+	h.Router.PrefixProvider = provider
+
+	assertReplies(t, "!ping", send(h, "!ping"), "ping:")
+	assertReplies(t, "?ping", send(h, "?ping"))
+
+	provider.SetGuildPrefixes(h.GuildID, ">", "$")
+	assertReplies(t, "$ping", send(h, "$ping"), "ping:")
+	assertReplies(t, "!ping", send(h, "!ping"))
+
+	provider.SetChannelPrefixes(h.ChannelID, "%")
+	assertReplies(t, "%ping", send(h, "%ping"), "ping:")
+	assertReplies(t, ">ping", send(h, ">ping"))
+
+	provider.SetChannelPrefixes(h.ChannelID)
+	provider.SetGuildPrefixes(h.GuildID)
+	assertReplies(t, "!ping", send(h, "!ping"), "ping:")
+}
+
+func TestFilePrefixProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prefixes.json")
+	provider, err := cmdlr2.NewFilePrefixProvider(path, "!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.SetGuildPrefixes(1, ">"); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.SetChannelPrefixes(2, "%"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := cmdlr2.NewFilePrefixProvider(path, "?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		guildID, channelID disgord.Snowflake
+		expected           string
+	}{
+		{1, 3, ">"},
+		{1, 2, "%"},
+		{5, 3, "!"},
+	}
+	for _, test := range tests {
+		prefixes, err := loaded.Prefixes(&disgord.MessageCreate{Message: &disgord.Message{GuildID: test.guildID, ChannelID: test.channelID}})
+		if err != nil || len(prefixes) != 1 || prefixes[0] != test.expected {
+			t.Errorf("guild %v, channel %v: expected %q, got %v (%v)", test.guildID, test.channelID, test.expected, prefixes, err)
+		}
+	}
+}
+
+func TestMentionPrefix(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMD(echoCommand("ping"))
+	h.Router.PingHandler = func(ctx *cmdlr2.Ctx) {
+		_ = ctx.ResponseText("pong")
+	}
+
+	mention := "<@" + h.Bot.ID.String() + ">"
+	assertReplies(t, "mention ping", send(h, mention+" ping"))
+
+	h.Router.MentionPrefix = true
+	assertReplies(t, "mention ping", send(h, mention+" ping x"), "ping:x")
+	assertReplies(t, "nick mention ping", send(h, "<@!"+h.Bot.ID.String()+"> ping"), "ping:")
+	assertReplies(t, "bare mention", send(h, mention), "pong")
+	assertReplies(t, "!ping", send(h, "!ping"), "ping:")
+}
@@ -0,0 +1,164 @@
+package cmdlr2
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+// ErrPromptTimeout is returned by Prompt and Confirm if the user doesn't answer in time
+var ErrPromptTimeout = errors.New("the user didn't answer in time")
+
+const (
+	confirmYes = "✅"
+	confirmNo  = "❌"
+	// confirmButtonPrefix prefixes the custom IDs of the confirmation buttons
+	confirmButtonPrefix = "cmdlr2:confirm:"
+)
+
+// Prompt sends the given question, if it isn't empty, and waits for the next message of the invoking user in the same
+// channel. The answer isn't handled as a command. If the user doesn't answer within the given timeout,
+// ErrPromptTimeout is returned, if the context of the execution is done before, its error is returned. As the context
+// ends with the CommandTimeout of the router or the Timeout of the command, those have to be longer than the timeout
+// of the prompt, otherwise the prompt ends with context.DeadlineExceeded.
+//
+// Prompt blocks the execution, with a worker pool it also blocks a worker.
+func (ctx *Ctx) Prompt(question string, timeout time.Duration) (*disgord.Message, error) {
+	message := ctx.Event.Message
+	answers := make(chan *disgord.Message, 1)
+	cancel := ctx.Router.awaitMessage(message.ChannelID, message.Author.ID, func(answer *disgord.Message) {
+		answers <- answer
+	})
+	defer cancel()
+
+	if question != "" {
+		if err := ctx.ResponseText(question); err != nil {
+			return nil, err
+		}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case answer := <-answers:
+		return answer, nil
+	case <-timer.C:
+		return nil, ErrPromptTimeout
+	case <-ctx.context().Done():
+		return nil, ctx.context().Err()
+	}
+}
+
+// Confirm sends the given question and waits for the invoking user to confirm or decline it. The transport decides
+// between buttons, if it implements ComponentResponder like the DisgordInteractionTransport, and ✅ and ❌ reactions,
+// which the DisgordTransport falls back to. Only the invoking user can answer, the controls are removed afterwards.
+// Timeouts, including the one of the command, and cancellation are handled like by Prompt.
+func (ctx *Ctx) Confirm(question string, timeout time.Duration) (bool, error) {
+	transport := ctx.Router.Transport
+	channelID := ctx.Event.Message.ChannelID
+	userID := ctx.Event.Message.Author.ID
+	params := &disgord.CreateMessageParams{Content: question}
+
+	answers := make(chan bool, 1)
+	answer := func(confirmed bool) {
+		select {
+		case answers <- confirmed:
+		default:
+		}
+	}
+
+	// The handlers are registered before sending so no answer gets lost, they wait for the message ID
+	sent := make(chan struct{})
+	var messageID disgord.Snowflake
+	isQuestion := func(id disgord.Snowflake) bool {
+		<-sent
+		return !messageID.IsZero() && id == messageID
+	}
+
+	var message *disgord.Message
+	var err error
+	var unregister, removeControls func()
+	if components, ok := transport.(ComponentResponder); ok {
+		// The buttons are removed either by the answer or afterwards, whichever comes first
+		var removed sync.Once
+		unregister = ctx.Router.RegisterComponentHandler(func(interaction *Interaction) {
+			if !isQuestion(interaction.Message.ID) {
+				return
+			}
+			author := interaction.Author()
+			customID := interaction.Data.CustomID
+			if author == nil || author.ID != userID ||
+				(customID != confirmButtonPrefix+"yes" && customID != confirmButtonPrefix+"no") {
+				_ = components.UpdateComponentMessage(ctx.Router.lifetimeContext(), interaction, nil)
+				return
+			}
+
+			removed.Do(func() {
+				_ = components.UpdateComponentMessage(ctx.Router.lifetimeContext(), interaction, &MessageEdit{Buttons: []*Button{}})
+			})
+			answer(customID == confirmButtonPrefix+"yes")
+		})
+		removeControls = func() {
+			removed.Do(func() {
+				_, _ = transport.EditMessage(ctx.Router.lifetimeContext(), channelID, messageID, &MessageEdit{Buttons: []*Button{}})
+			})
+		}
+
+		message, err = components.SendMessageWithButtons(ctx.context(), channelID, params, []*Button{
+			{CustomID: confirmButtonPrefix + "yes", Label: "Yes", Style: ButtonSuccess},
+			{CustomID: confirmButtonPrefix + "no", Label: "No", Style: ButtonDanger},
+		})
+	} else {
+		unregister = ctx.Router.RegisterReactionHandler(func(event *disgord.MessageReactionAdd) {
+			if event.UserID != userID || event.PartialEmoji == nil || !isQuestion(event.MessageID) {
+				return
+			}
+			switch event.PartialEmoji.Name {
+			case confirmYes:
+				answer(true)
+			case confirmNo:
+				answer(false)
+			}
+		})
+		removeControls = func() {
+			if bot, err := ctx.Router.CurrentUser(); err == nil {
+				_ = transport.RemoveReaction(ctx.Router.lifetimeContext(), channelID, messageID, confirmYes, bot.ID)
+				_ = transport.RemoveReaction(ctx.Router.lifetimeContext(), channelID, messageID, confirmNo, bot.ID)
+			}
+		}
+
+		message, err = ctx.Responder.SendMessage(ctx.context(), channelID, params)
+	}
+	if err != nil {
+		close(sent)
+		unregister()
+		return false, err
+	}
+	messageID = message.ID
+	close(sent)
+
+	if _, ok := transport.(ComponentResponder); !ok {
+		_ = transport.AddReaction(ctx.context(), channelID, messageID, confirmYes)
+		_ = transport.AddReaction(ctx.context(), channelID, messageID, confirmNo)
+	}
+
+	defer func() {
+		unregister()
+		removeControls()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case confirmed := <-answers:
+		return confirmed, nil
+	case <-timer.C:
+		return false, ErrPromptTimeout
+	case <-ctx.context().Done():
+		return false, ctx.context().Err()
+	}
+}
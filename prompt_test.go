@@ -0,0 +1,117 @@
+package cmdlr2_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+	"github.com/zackartz/cmdlr2/cmdlrtest"
+)
+
+// reactionTransport hides the button support of the wrapped transport
+type reactionTransport struct {
+	cmdlr2.Transport
+}
+
+// waitForMessage waits until the router sent a message with the given content
+func waitForMessage(t *testing.T, h *cmdlrtest.Harness, content string) *disgord.Message {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		for _, message := range h.Transport.Messages() {
+			if message.Content == content {
+				return message
+			}
+		}
+	}
+	t.Fatalf("expected a message %q", content)
+	return nil
+}
+
+func confirmCommand() *cmdlr2.Command {
+	return &cmdlr2.Command{
+		Name: "purge",
+		HandlerE: func(ctx *cmdlr2.Ctx) error {
+			confirmed, err := ctx.Confirm("Really?", time.Second)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return ctx.ResponseText("Cancelled")
+			}
+			return ctx.ResponseText("Purged")
+		},
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMD(echoCommand("ping"))
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name: "rename",
+		HandlerE: func(ctx *cmdlr2.Ctx) error {
+			timeout := time.Second
+			if ctx.Args.Amount() > 0 {
+				timeout = time.Millisecond
+			}
+			answer, err := ctx.Prompt("New name?", timeout)
+			if err != nil {
+				return err
+			}
+			return ctx.ResponseText("Renamed to " + answer.Content)
+		},
+	})
+
+	go h.Send("!rename")
+	waitForMessage(t, h, "New name?")
+	h.SendAs(&disgord.User{ID: 1}, "other user")
+	h.Send("!ping")
+	waitForMessage(t, h, "Renamed to !ping")
+	for _, message := range h.Transport.Messages() {
+		if message.Content == "ping:" {
+			t.Errorf("expected the answer not to be handled as a command")
+		}
+	}
+
+	assertReplies(t, "!rename fast", send(h, "!rename fast"), "New name?", "You didn't answer in time.")
+}
+
+func TestConfirmReactions(t *testing.T) {
+	h := newHarness()
+	h.Router.Transport = reactionTransport{h.Transport}
+	h.Router.RegisterCMD(confirmCommand())
+
+	go h.Send("!purge")
+	question := waitForMessage(t, h, "Really?")
+	h.ReactAs(&disgord.User{ID: 1}, question.ID, "❌")
+	h.React(question.ID, "✅")
+	waitForMessage(t, h, "Purged")
+
+	for _, message := range h.Transport.Messages() {
+		if message.Content == "Cancelled" {
+			t.Errorf("expected reactions of other users to be ignored")
+		}
+	}
+	if reactions := h.Transport.Reactions(question.ID); len(reactions) != 0 {
+		t.Errorf("expected the reactions to be removed, got %v", reactions)
+	}
+}
+
+func TestConfirmButtons(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMD(confirmCommand())
+
+	go h.Send("!purge")
+	question := waitForMessage(t, h, "Really?")
+	buttons := h.Transport.Buttons(question.ID)
+	if len(buttons) != 2 {
+		t.Fatalf("expected two buttons, got %v", buttons)
+	}
+	// The command listens for clicks before the question is sent
+	h.Click(question.ID, buttons[1].CustomID)
+	waitForMessage(t, h, "Cancelled")
+
+	if buttons := h.Transport.Buttons(question.ID); len(buttons) != 0 {
+		t.Errorf("expected the buttons to be removed, got %v", buttons)
+	}
+}
@@ -0,0 +1,266 @@
+package cmdlr2
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/andersfylling/disgord"
+)
+
+// ErrLookupUnsupported is returned by the resolvers if the transport of the router doesn't implement EntitySource
+var ErrLookupUnsupported = errors.New("the transport can't look up entities")
+
+// EntityNotFoundError is returned by the resolvers if no entity matches the given argument
+type EntityNotFoundError struct {
+	// Kind is the kind of the searched entity like `user` or `role`
+	Kind  string
+	Query string
+	// Err is set if looking up the entity failed
+	Err error
+}
+
+func (e *EntityNotFoundError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("no %s matching `%s` found: %s", e.Kind, e.Query, e.Err)
+	}
+	return fmt.Sprintf("no %s matching `%s` found", e.Kind, e.Query)
+}
+
+func (e *EntityNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	regexExactUserMention    = regexp.MustCompile("^<@!?(\\d+)>$")
+	regexExactRoleMention    = regexp.MustCompile("^<@&(\\d+)>$")
+	regexExactChannelMention = regexp.MustCompile("^<#(\\d+)>$")
+	regexExactEmoji          = regexp.MustCompile("^<(a?):(\\w+):(\\d+)>$")
+)
+
+// parseID parses the given string as a mention matching the given regex or as a raw snowflake
+func parseID(raw string, mention *regexp.Regexp) (disgord.Snowflake, bool) {
+	if submatches := mention.FindStringSubmatch(raw); submatches != nil {
+		raw = submatches[1]
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return disgord.Snowflake(id), true
+}
+
+func (ctx *Ctx) entitySource() (EntitySource, error) {
+	if ctx.Router == nil {
+		return nil, ErrLookupUnsupported
+	}
+	source, ok := ctx.Router.Transport.(EntitySource)
+	if !ok {
+		return nil, ErrLookupUnsupported
+	}
+	return source, nil
+}
+
+// matchesUser checks whether the given query is the tag (`name#0001`) or the name of the given user
+func matchesUser(user *disgord.User, query string) bool {
+	if user == nil {
+		return false
+	}
+	return Equals(user.Tag(), query, true) || Equals(user.Username, query, true)
+}
+
+// ResolveUser resolves a user from a mention, a snowflake, a tag like `name#0001` or a name. Names are searched
+// case-insensitively in the current message and the members of the current guild.
+func (ctx *Ctx) ResolveUser(raw string) (*disgord.User, error) {
+	source, err := ctx.entitySource()
+	if err != nil {
+		return nil, err
+	}
+
+	if id, ok := parseID(raw, regexExactUserMention); ok {
+		user, err := source.User(ctx.context(), id)
+		if err != nil {
+			return nil, &EntityNotFoundError{Kind: "user", Query: raw, Err: err}
+		}
+		return user, nil
+	}
+
+	msg := ctx.Event.Message
+	for _, user := range append([]*disgord.User{msg.Author}, msg.Mentions...) {
+		if matchesUser(user, raw) {
+			return user, nil
+		}
+	}
+
+	if msg.GuildID.IsZero() {
+		return nil, &EntityNotFoundError{Kind: "user", Query: raw}
+	}
+	member, err := ctx.findMember(source, raw, "user")
+	if err != nil {
+		return nil, err
+	}
+	return member.User, nil
+}
+
+// ResolveMember resolves a member of the current guild from a mention, a snowflake, a tag like `name#0001`, a name or
+// a nickname. Names and nicknames are matched case-insensitively.
+func (ctx *Ctx) ResolveMember(raw string) (*disgord.Member, error) {
+	source, err := ctx.entitySource()
+	if err != nil {
+		return nil, err
+	}
+
+	guildID := ctx.Event.Message.GuildID
+	if guildID.IsZero() {
+		return nil, &EntityNotFoundError{Kind: "member", Query: raw}
+	}
+
+	if id, ok := parseID(raw, regexExactUserMention); ok {
+		member, err := source.Member(ctx.context(), guildID, id)
+		if err != nil {
+			return nil, &EntityNotFoundError{Kind: "member", Query: raw, Err: err}
+		}
+		return member, nil
+	}
+	return ctx.findMember(source, raw, "member")
+}
+
+// findMember searches the members of the current guild by tag, name and nickname. If the source may return an
+// incomplete list, all members are fetched if none of them matches. Kind is used for the error.
+func (ctx *Ctx) findMember(source EntitySource, query, kind string) (*disgord.Member, error) {
+	guildID := ctx.Event.Message.GuildID
+	members, err := source.Members(ctx.context(), guildID)
+	if err != nil {
+		return nil, err
+	}
+	if member := matchMember(members, query); member != nil {
+		return member, nil
+	}
+
+	if fetcher, ok := source.(MemberFetcher); ok {
+		if members, err = fetcher.FetchMembers(ctx.context(), guildID); err != nil {
+			return nil, err
+		}
+		if member := matchMember(members, query); member != nil {
+			return member, nil
+		}
+	}
+	return nil, &EntityNotFoundError{Kind: kind, Query: query}
+}
+
+// matchMember returns the first of the given members whose tag, name or nickname matches the given query
+func matchMember(members []*disgord.Member, query string) *disgord.Member {
+	for _, member := range members {
+		if matchesUser(member.User, query) || (member.Nick != "" && Equals(member.Nick, query, true)) {
+			return member
+		}
+	}
+	return nil
+}
+
+// ResolveRole resolves a role of the current guild from a mention, a snowflake or a case-insensitive name
+func (ctx *Ctx) ResolveRole(raw string) (*disgord.Role, error) {
+	source, err := ctx.entitySource()
+	if err != nil {
+		return nil, err
+	}
+
+	guildID := ctx.Event.Message.GuildID
+	if guildID.IsZero() {
+		return nil, &EntityNotFoundError{Kind: "role", Query: raw}
+	}
+
+	roles, err := source.Roles(ctx.context(), guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, isID := parseID(raw, regexExactRoleMention)
+	for _, role := range roles {
+		if (isID && role.ID == id) || (!isID && Equals(role.Name, raw, true)) {
+			return role, nil
+		}
+	}
+	return nil, &EntityNotFoundError{Kind: "role", Query: raw}
+}
+
+// ResolveChannel resolves a channel of the current guild from a mention, a snowflake or a case-insensitive name with
+// or without a leading `#`. Outside of guilds only mentions and snowflakes are resolved.
+func (ctx *Ctx) ResolveChannel(raw string) (*disgord.Channel, error) {
+	source, err := ctx.entitySource()
+	if err != nil {
+		return nil, err
+	}
+
+	guildID := ctx.Event.Message.GuildID
+	if id, ok := parseID(raw, regexExactChannelMention); ok {
+		channel, err := source.Channel(ctx.context(), id)
+		if err != nil {
+			return nil, &EntityNotFoundError{Kind: "channel", Query: raw, Err: err}
+		}
+		// Don't leak channels of other guilds
+		if !guildID.IsZero() && channel.GuildID != guildID {
+			return nil, &EntityNotFoundError{Kind: "channel", Query: raw}
+		}
+		return channel, nil
+	}
+
+	if guildID.IsZero() {
+		return nil, &EntityNotFoundError{Kind: "channel", Query: raw}
+	}
+
+	channels, err := source.Channels(ctx.context(), guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimPrefix(raw, "#")
+	for _, channel := range channels {
+		if Equals(channel.Name, name, true) {
+			return channel, nil
+		}
+	}
+	return nil, &EntityNotFoundError{Kind: "channel", Query: raw}
+}
+
+// ResolveEmoji resolves a custom emoji from an emoji like `<:name:id>`, a snowflake or a case-insensitive name with or
+// without colons. Emojis of other guilds are only resolved from the `<:name:id>` form.
+func (ctx *Ctx) ResolveEmoji(raw string) (*disgord.Emoji, error) {
+	var emojis []*disgord.Emoji
+	if guildID := ctx.Event.Message.GuildID; !guildID.IsZero() {
+		source, err := ctx.entitySource()
+		if err != nil {
+			return nil, err
+		}
+		emojis, err = source.Emojis(ctx.context(), guildID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if submatches := regexExactEmoji.FindStringSubmatch(raw); submatches != nil {
+		id, _ := strconv.ParseUint(submatches[3], 10, 64)
+		for _, emoji := range emojis {
+			if emoji.ID == disgord.Snowflake(id) {
+				return emoji, nil
+			}
+		}
+		return &disgord.Emoji{
+			ID:       disgord.Snowflake(id),
+			Name:     submatches[2],
+			Animated: submatches[1] == "a",
+		}, nil
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 64)
+	isID := err == nil
+	name := strings.Trim(raw, ":")
+	for _, emoji := range emojis {
+		if (isID && emoji.ID == disgord.Snowflake(id)) || (!isID && Equals(emoji.Name, name, true)) {
+			return emoji, nil
+		}
+	}
+	return nil, &EntityNotFoundError{Kind: "emoji", Query: raw}
+}
@@ -0,0 +1,144 @@
+package cmdlr2_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+)
+
+func TestResolvers(t *testing.T) {
+	h := newHarness()
+	alice := &disgord.User{ID: 10, Username: "Alice", Discriminator: 1234}
+	h.Transport.AddMember(h.GuildID, &disgord.Member{User: alice, Nick: "Ali"})
+	h.Transport.AddRole(h.GuildID, &disgord.Role{ID: 20, Name: "Moderators"})
+	h.Transport.AddChannel(&disgord.Channel{ID: 30, GuildID: h.GuildID, Name: "general"})
+	h.Transport.AddChannel(&disgord.Channel{ID: 31, GuildID: 1, Name: "elsewhere"})
+	h.Transport.AddEmoji(h.GuildID, &disgord.Emoji{ID: 40, Name: "pepe"})
+
+	h.Router.RegisterCMDList([]*cmdlr2.Command{
+		{
+			Name: "user",
+			HandlerE: func(ctx *cmdlr2.Ctx) error {
+				user, err := ctx.ResolveUser(ctx.Args.Raw())
+				if err != nil {
+					return err
+				}
+				return ctx.ResponseText(user.ID.String())
+			},
+		},
+		{
+			Name: "member",
+			HandlerE: func(ctx *cmdlr2.Ctx) error {
+				member, err := ctx.ResolveMember(ctx.Args.Raw())
+				if err != nil {
+					return err
+				}
+				return ctx.ResponseText(member.Nick)
+			},
+		},
+		{
+			Name: "role",
+			HandlerE: func(ctx *cmdlr2.Ctx) error {
+				role, err := ctx.ResolveRole(ctx.Args.Raw())
+				if err != nil {
+					return err
+				}
+				return ctx.ResponseText(role.Name)
+			},
+		},
+		{
+			Name: "channel",
+			HandlerE: func(ctx *cmdlr2.Ctx) error {
+				channel, err := ctx.ResolveChannel(ctx.Args.Raw())
+				if err != nil {
+					return err
+				}
+				return ctx.ResponseText(channel.Name)
+			},
+		},
+		{
+			Name: "emoji",
+			HandlerE: func(ctx *cmdlr2.Ctx) error {
+				emoji, err := ctx.ResolveEmoji(ctx.Args.Raw())
+				if err != nil {
+					return err
+				}
+				return ctx.ResponseText(emoji.Name + ":" + emoji.ID.String())
+			},
+		},
+	})
+
+	tests := map[string]string{
+		"!user <@10>":         "10",
+		"!user <@!10>":        "10",
+		"!user 10":            "10",
+		"!user alice#1234":    "10",
+		"!user ALICE":         "10",
+		"!user ali":           "10",
+		"!user bob":           "Couldn't find any user matching `bob`.",
+		"!member <@10>":       "Ali",
+		"!member Alice#1234":  "Ali",
+		"!member 11":          "Couldn't find any member matching `11`.",
+		"!role <@&20>":        "Moderators",
+		"!role 20":            "Moderators",
+		"!role moderators":    "Moderators",
+		"!role admins":        "Couldn't find any role matching `admins`.",
+		"!channel <#30>":      "general",
+		"!channel #General":   "general",
+		"!channel <#31>":      "Couldn't find any channel matching `<#31>`.",
+		"!emoji <:pepe:40>":   "pepe:40",
+		"!emoji :PEPE:":       "pepe:40",
+		"!emoji 40":           "pepe:40",
+		"!emoji <a:other:50>": "other:50",
+		"!emoji unknown":      "Couldn't find any emoji matching `unknown`.",
+	}
+	for input, expected := range tests {
+		assertReplies(t, input, send(h, input), expected)
+	}
+}
+
+// cachedTransport only returns the first member of a guild from Members like a partially filled cache
+type cachedTransport struct {
+	*cmdlr2.MemoryTransport
+	fetched int
+}
+
+func (t *cachedTransport) Members(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Member, error) {
+	members, err := t.MemoryTransport.Members(ctx, guildID)
+	return members[:1], err
+}
+
+func (t *cachedTransport) FetchMembers(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Member, error) {
+	t.fetched++
+	return t.MemoryTransport.Members(ctx, guildID)
+}
+
+func TestResolveUncachedMember(t *testing.T) {
+	h := newHarness()
+	h.Transport.AddMember(h.GuildID, &disgord.Member{User: &disgord.User{ID: 10, Username: "alice"}, Nick: "Ali"})
+	h.Transport.AddMember(h.GuildID, &disgord.Member{User: &disgord.User{ID: 11, Username: "bob"}, Nick: "Bobby"})
+	transport := &cachedTransport{MemoryTransport: h.Transport}
+	h.Router.Transport = transport
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name: "member",
+		HandlerE: func(ctx *cmdlr2.Ctx) error {
+			member, err := ctx.ResolveMember(ctx.Args.Raw())
+			if err != nil {
+				return err
+			}
+			return ctx.ResponseText(member.Nick)
+		},
+	})
+
+	assertReplies(t, "!member alice", send(h, "!member alice"), "Ali")
+	if transport.fetched != 0 {
+		t.Errorf("expected cached members not to be fetched, got %d fetches", transport.fetched)
+	}
+	assertReplies(t, "!member bob", send(h, "!member bob"), "Bobby")
+	assertReplies(t, "!member carol", send(h, "!member carol"), "Couldn't find any member matching `carol`.")
+	if transport.fetched != 2 {
+		t.Errorf("expected uncached members to be fetched, got %d fetches", transport.fetched)
+	}
+}
@@ -1,132 +1,436 @@
 package cmdlr2
 
 import (
+	"context"
 	"fmt"
-	"github.com/andersfylling/disgord"
-	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andersfylling/disgord"
 )
 
 type Router struct {
-	Prefixes         []string
+	Prefixes []string
+	// PrefixProvider resolves the prefixes per message, Prefixes is used as a fallback if it is nil or fails
+	PrefixProvider PrefixProvider
+	// MentionPrefix allows to use a mention of the bot as a prefix like `@bot ping`
+	MentionPrefix    bool
 	IgnorePrefixCase bool
 	BotsAllowed      bool
 	Commands         []*Command
 	Client           *disgord.Client
+	Transport        Transport
 	Middlewares      []Middleware
 	PingHandler      ExecutionHandler
-	Storage          map[string]*ObjectsMap
+	// ErrorHandler receives all errors occurring while executing a command, including recovered panics. If it is nil,
+	// DefaultErrorHandler is used.
+	ErrorHandler func(ctx *Ctx, err error)
+	// Workers enables the worker pool if it is greater than zero. Commands are then queued and executed by this many
+	// goroutines instead of the goroutine of the transport.
+	Workers int
+	// QueueSize limits the amount of commands waiting for a worker, it defaults to Workers
+	QueueSize int
+	// GuildQueueSize limits the amount of waiting commands per guild, 0 means no limit. The guilds take turns either way.
+	GuildQueueSize  int
+	QueueFullPolicy QueueFullPolicy
+	// HelpRenderer renders the default help command, a DefaultHelpRenderer is used if it is nil
+	HelpRenderer HelpRenderer
+	// Locale returns the locale like `de` used to answer the given context, for example by the locale of the guild or
+	// the user
+	Locale func(ctx *Ctx) string
+	// CommandTimeout limits the execution time of every command unless the command declares its own Timeout
+	CommandTimeout time.Duration
+	// CooldownStore keeps track of the command cooldowns, Create sets up an in-memory store
+	CooldownStore CooldownStore
+	// Owners are the users allowed to use commands marked as OwnerOnly
+	Owners []disgord.Snowflake
+	// Storage holds the named storages used by commands to keep state between executions
+	Storage *Storages
+	// StorageSize limits the amount of entries of every storage created by default if Storage is set up by the router.
+	// 0 means no limit.
+	StorageSize int
+
+	mutex            sync.RWMutex
+	botUser          *disgord.User
+	reactionHandlers []*reactionHandler
+	// componentHandlers receive the clicked buttons, they share the IDs of the reaction handlers
+	componentHandlers []*componentHandler
+	lastHandlerID     uint64
+	// messageWaiters receive the next message of a user in a channel instead of the commands
+	messageWaiters []*messageWaiter
+	// index holds the *commandIndex used to resolve commands
+	index atomic.Value
+	// lifetime is the parent context of all executions, it is cancelled by stop
+	lifetime context.Context
+	stop     context.CancelFunc
+	// running tracks the executing handlers, closed is set once the router shuts down
+	running sync.WaitGroup
+	closed  bool
+	pool    *workerPool
+	// argumentTypes holds the custom parameter types
+	argumentTypes map[ParamType]*paramConverter
+}
+
+type reactionHandler struct {
+	id      uint64
+	handler func(event *disgord.MessageReactionAdd)
 }
 
 func Create(router *Router) *Router {
-	router.Storage = map[string]*ObjectsMap{}
+	if router.Storage == nil {
+		router.Storage = NewStorages()
+		router.Storage.MaxEntries = router.StorageSize
+	}
+	if router.CooldownStore == nil {
+		router.CooldownStore = NewMemoryCooldownStore()
+	}
+	router.lifetime, router.stop = context.WithCancel(context.Background())
+	router.Reindex()
 	return router
 }
 
-func (r *Router) RegisterCMD(command *Command) {
-	r.Commands = append(r.Commands, command)
+// lifetimeContext returns the context all executions are derived from
+func (r *Router) lifetimeContext() context.Context {
+	r.mutex.RLock()
+	lifetime := r.lifetime
+	r.mutex.RUnlock()
+	if lifetime != nil {
+		return lifetime
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.lifetime == nil {
+		r.lifetime, r.stop = context.WithCancel(context.Background())
+	}
+	return r.lifetime
 }
 
-func (r *Router) RegisterCMDList(commands []*Command) {
-	r.Commands = append(r.Commands, commands...)
+// RegisterCMD registers the given command. It returns a *DuplicateCommandError and doesn't register the command if
+// its name, one of its aliases or one of its sub commands collides with another command.
+func (r *Router) RegisterCMD(command *Command) error {
+	return r.RegisterCMDList([]*Command{command})
 }
 
-func (r *Router) GetCmd(name string) *Command {
-	sort.Slice(r.Commands, func(i, j int) bool {
-		return len(r.Commands[i].Name) > len(r.Commands[j].Name)
-	})
+// RegisterCMDList registers the given commands. If one of them collides with another command, none of them gets
+// registered and a *DuplicateCommandError is returned. Custom types used by their parameters and flags have to be
+// registered before, otherwise an *UnknownTypeError is returned.
+func (r *Router) RegisterCMDList(commands []*Command) error {
+	r.mutex.Lock()
+	if err := findDuplicate("", r.Commands, commands); err != nil {
+		r.mutex.Unlock()
+		return err
+	}
+	if err := r.findUnknownType("", commands); err != nil {
+		r.mutex.Unlock()
+		return err
+	}
+	r.Commands = append(r.Commands, commands...)
+	r.mutex.Unlock()
 
-	for _, cmd := range r.Commands {
-		toCheck := make([]string, len(cmd.Aliases)+1)
-		toCheck = append(toCheck, cmd.Name)
-		toCheck = append(toCheck, cmd.Aliases...)
-		sort.Slice(toCheck, func(i, j int) bool {
-			return len(toCheck[i]) > len(toCheck[j])
-		})
+	r.Reindex()
+	return nil
+}
 
-		if StringArrayContains(toCheck, name, cmd.IgnoreCase) {
-			return cmd
-		}
+// Validate checks all commands and their sub commands for colliding names and aliases and unknown types. It only has to
+// be called if Commands or SubCommands were modified directly as the register functions already reject both.
+func (r *Router) Validate() error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if err := findDuplicate("", nil, r.Commands); err != nil {
+		return err
+	}
+	if err := r.findUnknownType("", r.Commands); err != nil {
+		return err
 	}
 	return nil
 }
 
+// Reindex rebuilds the lookup index of all commands and sub commands. It is called by the register functions and only
+// has to be called manually if Commands or SubCommands are modified directly after the router was created.
+func (r *Router) Reindex() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.index.Store(newCommandIndex(r.Commands))
+}
+
+// commandIndex returns the lookup index, it is built once if the router wasn't made by Create
+func (r *Router) commandIndex() *commandIndex {
+	if index, ok := r.index.Load().(*commandIndex); ok {
+		return index
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if index, ok := r.index.Load().(*commandIndex); ok {
+		return index
+	}
+	index := newCommandIndex(r.Commands)
+	r.index.Store(index)
+	return index
+}
+
+// GetCmd returns the command with the given name or alias
+func (r *Router) GetCmd(name string) *Command {
+	return r.commandIndex().commands.lookup(name)
+}
+
+// GetSubCmd returns the sub command of the given command with the given name or alias
+func (r *Router) GetSubCmd(command *Command, name string) *Command {
+	if command == nil {
+		return nil
+	}
+	if index, ok := r.commandIndex().subCommands[command]; ok {
+		return index.lookup(name)
+	}
+	return command.GetSubCommand(name)
+}
+
+// RegisterMiddleware registers a middleware which runs for every command of the router
 func (r *Router) RegisterMiddleware(middleware Middleware) {
 	r.Middlewares = append(r.Middlewares, middleware)
 }
 
-func (r *Router) InitializeStorage(name string) {
-	r.Storage[name] = NewObjectsMap()
+// RegisterReactionHandler registers a handler which gets called for every reaction the transport delivers. The
+// returned function unregisters the handler again.
+func (r *Router) RegisterReactionHandler(handler func(event *disgord.MessageReactionAdd)) func() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lastHandlerID++
+	id := r.lastHandlerID
+	r.reactionHandlers = append(r.reactionHandlers, &reactionHandler{id: id, handler: handler})
+
+	return func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		// Copy the handlers as HandleReaction iterates over them without holding the lock
+		handlers := make([]*reactionHandler, 0, len(r.reactionHandlers))
+		for _, h := range r.reactionHandlers {
+			if h.id != id {
+				handlers = append(handlers, h)
+			}
+		}
+		r.reactionHandlers = handlers
+	}
+}
+
+// InitializeStorage returns the storage with the given name, creating it if it doesn't exist yet
+func (r *Router) InitializeStorage(name string) Storage {
+	r.mutex.Lock()
+	if r.Storage == nil {
+		r.Storage = NewStorages()
+		r.Storage.MaxEntries = r.StorageSize
+	}
+	storages := r.Storage
+	r.mutex.Unlock()
+
+	return storages.Initialize(name)
 }
 
+// Initialize connects the router to the given disgord client
 func (r *Router) Initialize(client *disgord.Client) {
-	client.Gateway().MessageCreate(r.Handler(client))
+	r.Client = client
+	r.InitializeTransport(NewDisgordTransport(client))
+}
+
+// InitializeTransport connects the router to the given transport
+func (r *Router) InitializeTransport(transport Transport) {
+	r.Transport = transport
+	transport.OnMessageCreate(r.HandleMessage)
+	transport.OnMessageReactionAdd(r.HandleReaction)
+	if source, ok := transport.(InteractionSource); ok {
+		source.OnInteractionCreate(r.HandleInteraction)
+	}
 }
 
+// Handler returns a disgord message handler dispatching into the router. Initialize should be preferred over
+// registering this handler manually.
 func (r *Router) Handler(c *disgord.Client) disgord.HandlerMessageCreate {
-	return func(s disgord.Session, h *disgord.MessageCreate) {
-		msg := h.Message
-		content := h.Message.Content
+	if r.Client == nil {
+		r.Client = c
+	}
+	if r.Transport == nil {
+		r.Transport = NewDisgordTransport(c)
+	}
 
-		if msg.Author.Bot && !r.BotsAllowed {
-			return
-		}
+	return func(_ disgord.Session, h *disgord.MessageCreate) {
+		r.HandleMessage(h)
+	}
+}
+
+// CurrentUser returns the user the bot is logged in as. The user is fetched once and cached afterwards.
+func (r *Router) CurrentUser() (*disgord.User, error) {
+	r.mutex.RLock()
+	u := r.botUser
+	r.mutex.RUnlock()
+	if u != nil {
+		return u, nil
+	}
+
+	u, err := r.Transport.CurrentUser()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	r.botUser = u
+	r.mutex.Unlock()
+	return u, nil
+}
+
+// HandleReaction passes the given reaction to all registered reaction handlers
+func (r *Router) HandleReaction(event *disgord.MessageReactionAdd) {
+	if !r.begin() {
+		return
+	}
+	defer r.running.Done()
+
+	r.mutex.RLock()
+	handlers := r.reactionHandlers
+	r.mutex.RUnlock()
 
-		u, _ := s.CurrentUser().Get()
+	message := &disgord.Message{ID: event.MessageID, ChannelID: event.ChannelID, Author: &disgord.User{ID: event.UserID}}
+	for _, h := range handlers {
+		r.runHandler(message, func() {
+			h.handler(event)
+		})
+	}
+}
 
-		if (content == fmt.Sprintf("<@!%v>", u.ID) || content == fmt.Sprintf("<@%v>", u.ID)) && r.PingHandler != nil {
-			r.PingHandler(&Ctx{
-				Session: &s,
-				Event:   h,
-				Client:  r.Client,
-				Args:    ParseArguments(""),
-				Router:  r,
+// HandleMessage dispatches the given message to the command matching it. If several names or aliases match, only the
+// command with the longest match gets executed.
+func (r *Router) HandleMessage(h *disgord.MessageCreate) {
+	msg := h.Message
+	content := h.Message.Content
+
+	if msg.Author.Bot && !r.BotsAllowed {
+		return
+	}
+
+	if waiter := r.takeMessageWaiter(msg); waiter != nil {
+		if r.begin() {
+			defer r.running.Done()
+			r.runHandler(msg, func() {
+				waiter.handler(msg)
 			})
-			return
 		}
+		return
+	}
 
-		hasPrefix, content := StringHasPrefix(content, r.Prefixes, r.IgnorePrefixCase)
-		if !hasPrefix {
+	if r.PingHandler != nil {
+		u, err := r.CurrentUser()
+		if err == nil && (content == fmt.Sprintf("<@!%v>", u.ID) || content == fmt.Sprintf("<@%v>", u.ID)) {
+			ctx := r.newCtx(h, ParseArguments(""), nil)
+			r.execute(ctx, func() {
+				defer ctx.recoverPanic()
+				r.PingHandler(ctx)
+			})
 			return
 		}
+	}
 
-		content = strings.Trim(content, " ")
-		if content == "" {
-			return
-		}
+	hasPrefix, content := StringHasPrefix(content, r.resolvePrefixes(h), r.IgnorePrefixCase)
+	if !hasPrefix {
+		return
+	}
+	prefix := msg.Content[:len(msg.Content)-len(content)]
 
-		for _, m := range r.Middlewares {
-			m.Trigger(Ctx{
-				Session: &s,
-				Event:   h,
-				Client:  c,
-				Router:  r,
-			})
-		}
+	content = strings.Trim(content, " ")
+	if content == "" {
+		return
+	}
 
-		for _, cmd := range r.Commands {
-			toCheck := BuildCheckPrefixes(cmd)
+	cmd, length := r.commandIndex().commands.match(content)
+	if cmd == nil {
+		return
+	}
 
-			isCommand, content := StringHasPrefix(content, toCheck, cmd.IgnoreCase)
+	_, content = StringHasPrefix(content[length:], []string{" ", "\n"}, false)
+	ctx := r.newCtx(h, ParseArguments(content), cmd)
+	ctx.Prefix = prefix
+	r.execute(ctx, func() {
+		cmd.Trigger(ctx)
+	})
+}
 
-			if !isCommand {
-				continue
-			}
+// begin registers a new execution. It returns false if the router is shut down, otherwise running.Done has to be
+// called once the execution is finished. The worker pool is started with the first execution.
+func (r *Router) begin() bool {
+	r.mutex.RLock()
+	if !r.closed && (r.Workers <= 0 || r.pool != nil) {
+		r.running.Add(1)
+		r.mutex.RUnlock()
+		return true
+	}
+	r.mutex.RUnlock()
 
-			isValid, content := StringHasPrefix(content, []string{" ", "\n"}, false)
-			if content == "" || isValid {
-				ctx := &Ctx{
-					Session: &s,
-					Event:   h,
-					Client:  c,
-					Args:    ParseArguments(content),
-					Router:  r,
-					Command: cmd,
-				}
-
-				cmd.Trigger(ctx)
-			}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return false
+	}
+	if r.Workers > 0 && r.pool == nil {
+		r.pool = newWorkerPool(r.Workers, r.QueueSize, r.GuildQueueSize)
+	}
+	r.running.Add(1)
+	return true
+}
+
+// Shutdown stops the router from handling new messages, reactions and interactions and waits for the running handlers
+// to finish. If the given context is done before, the contexts of the running handlers are cancelled and the error of
+// the given context is returned.
+func (r *Router) Shutdown(ctx context.Context) error {
+	r.lifetimeContext()
+
+	r.mutex.Lock()
+	r.closed = true
+	stop := r.stop
+	pool := r.pool
+	r.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.running.Wait()
+		close(done)
+	}()
+
+	defer func() {
+		stop()
+		if pool != nil {
+			pool.close()
 		}
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
+func (r *Router) newCtx(event *disgord.MessageCreate, args *Arguments, command *Command) *Ctx {
+	ctx := &Ctx{
+		Context:   r.lifetimeContext(),
+		Client:    r.Client,
+		Responder: r.Transport,
+		Event:     event,
+		Args:      args,
+		Command:   command,
+		Router:    r,
+	}
+	if r.Client != nil {
+		var session disgord.Session = r.Client
+		ctx.Session = &session
 	}
+	return ctx
 }
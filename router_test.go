@@ -0,0 +1,311 @@
+package cmdlr2_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+	"github.com/zackartz/cmdlr2/cmdlrtest"
+)
+
+func echoCommand(name string, aliases ...string) *cmdlr2.Command {
+	return &cmdlr2.Command{
+		Name:    name,
+		Aliases: aliases,
+		Handler: func(ctx *cmdlr2.Ctx) {
+			_ = ctx.ResponseText(ctx.Command.Name + ":" + ctx.Args.Raw())
+		},
+	}
+}
+
+func newHarness() *cmdlrtest.Harness {
+	return cmdlrtest.New(cmdlr2.Create(&cmdlr2.Router{
+		Prefixes:         []string{"!", "?"},
+		IgnorePrefixCase: true,
+	}))
+}
+
+func assertReplies(t *testing.T, input string, replies []string, expected ...string) {
+	t.Helper()
+	if strings.Join(replies, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("%q: expected replies %q, got %q", input, expected, replies)
+	}
+}
+
+func send(h *cmdlrtest.Harness, content string) []string {
+	var replies []string
+	for _, message := range h.Send(content) {
+		replies = append(replies, message.Content)
+	}
+	return replies
+}
+
+func TestPrefixMatching(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMD(echoCommand("ping"))
+
+	tests := map[string][]string{
+		"!ping":       {"ping:"},
+		"?ping a b":   {"ping:a b"},
+		"!ping\nline": {"ping:line"},
+		"ping":        nil,
+		"$ping":       nil,
+		"!pingpong":   nil,
+		"!":           nil,
+		"!PING":       nil,
+	}
+	for input, expected := range tests {
+		assertReplies(t, input, send(h, input), expected...)
+	}
+}
+
+func TestIgnoreCase(t *testing.T) {
+	h := newHarness()
+	cmd := echoCommand("ping", "p")
+	cmd.IgnoreCase = true
+	h.Router.RegisterCMD(cmd)
+
+	assertReplies(t, "!PiNg", send(h, "!PiNg"), "ping:")
+	assertReplies(t, "!P x", send(h, "!P x"), "ping:x")
+}
+
+func TestAliases(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMD(echoCommand("remove", "rm", "del"))
+
+	assertReplies(t, "!rm x", send(h, "!rm x"), "remove:x")
+	assertReplies(t, "!del", send(h, "!del"), "remove:")
+	assertReplies(t, "!delete", send(h, "!delete"))
+}
+
+func TestSubCommands(t *testing.T) {
+	h := newHarness()
+	cmd := echoCommand("config")
+	cmd.SubCommands = []*cmdlr2.Command{
+		echoCommand("set", "s"),
+		echoCommand("get"),
+	}
+	h.Router.RegisterCMD(cmd)
+
+	assertReplies(t, "!config", send(h, "!config"), "config:")
+	assertReplies(t, "!config set a b", send(h, "!config set a b"), "set:a b")
+	assertReplies(t, "!config s a", send(h, "!config s a"), "set:a")
+	assertReplies(t, "!config get", send(h, "!config get"), "get:")
+	assertReplies(t, "!config unknown", send(h, "!config unknown"), "config:unknown")
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	h := newHarness()
+
+	var order []string
+	record := func(name string) cmdlr2.Middleware {
+		return func(ctx *cmdlr2.Ctx, next cmdlr2.ExecutionHandler) {
+			order = append(order, name)
+			next(ctx)
+		}
+	}
+
+	sub := echoCommand("sub")
+	sub.RegisterMiddleware(record("sub"))
+	cmd := echoCommand("cmd")
+	cmd.RegisterMiddleware(record("cmd"))
+	cmd.SubCommands = []*cmdlr2.Command{sub}
+	h.Router.RegisterCMD(cmd)
+	h.Router.RegisterMiddleware(record("router1"))
+	h.Router.RegisterMiddleware(record("router2"))
+
+	assertReplies(t, "!cmd sub", send(h, "!cmd sub"), "sub:")
+	if strings.Join(order, ",") != "router1,router2,cmd,sub" {
+		t.Errorf("unexpected middleware order %v", order)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMD(echoCommand("secret"))
+	h.Router.RegisterMiddleware(func(ctx *cmdlr2.Ctx, next cmdlr2.ExecutionHandler) {
+		if ctx.Args.Get(0).Raw() != "password" {
+			_ = ctx.ResponseText("denied")
+			return
+		}
+		next(ctx)
+	})
+
+	assertReplies(t, "!secret", send(h, "!secret"), "denied")
+	assertReplies(t, "!secret password", send(h, "!secret password"), "secret:password")
+}
+
+func TestPingHandler(t *testing.T) {
+	h := newHarness()
+	h.Router.PingHandler = func(ctx *cmdlr2.Ctx) {
+		_ = ctx.ResponseText("pong")
+	}
+
+	assertReplies(t, "mention", send(h, "<@"+h.Bot.ID.String()+">"), "pong")
+	assertReplies(t, "nick mention", send(h, "<@!"+h.Bot.ID.String()+">"), "pong")
+	assertReplies(t, "other mention", send(h, "<@1>"))
+}
+
+func TestBotsIgnored(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMD(echoCommand("ping"))
+
+	if replies := h.SendAs(h.Bot, "!ping"); len(replies) != 0 {
+		t.Errorf("expected bots to be ignored, got %d replies", len(replies))
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	h := newHarness()
+	h.Router.RegisterCMDList([]*cmdlr2.Command{
+		{
+			Name: "fail",
+			HandlerE: func(ctx *cmdlr2.Ctx) error {
+				return cmdlr2.NewUserError("%s failed", ctx.Args.Raw())
+			},
+		},
+		{
+			Name: "broken",
+			HandlerE: func(ctx *cmdlr2.Ctx) error {
+				return errors.New("database unavailable")
+			},
+		},
+		{
+			Name: "panic",
+			Handler: func(ctx *cmdlr2.Ctx) {
+				panic("oops")
+			},
+		},
+	})
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	assertReplies(t, "!fail x", send(h, "!fail x"), "x failed")
+	assertReplies(t, "!broken", send(h, "!broken"), "Something went wrong while executing this command.")
+	assertReplies(t, "!panic", send(h, "!panic"), "Something went wrong while executing this command.")
+	if output := logged.String(); strings.Contains(output, "x failed") ||
+		!strings.Contains(output, "command `broken`: database unavailable") ||
+		!strings.Contains(output, "command `panic`: command panicked: oops") || !strings.Contains(output, "goroutine") {
+		t.Errorf("expected unexpected errors and the stack of panics to be logged, got %q", output)
+	}
+
+	var handled error
+	h.Router.ErrorHandler = func(ctx *cmdlr2.Ctx, err error) {
+		handled = err
+	}
+	assertReplies(t, "!panic", send(h, "!panic"))
+	var panicError *cmdlr2.PanicError
+	if !errors.As(handled, &panicError) || panicError.Value != "oops" {
+		t.Errorf("expected a recovered panic, got %v", handled)
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	h := newHarness()
+	var handled []interface{}
+	h.Router.ErrorHandler = func(ctx *cmdlr2.Ctx, err error) {
+		var panicError *cmdlr2.PanicError
+		if errors.As(err, &panicError) && ctx.Event.Message.ChannelID == h.ChannelID {
+			handled = append(handled, panicError.Value)
+		}
+	}
+	h.Router.RegisterReactionHandler(func(event *disgord.MessageReactionAdd) {
+		panic("reaction")
+	})
+	h.Router.RegisterComponentHandler(func(interaction *cmdlr2.Interaction) {
+		panic("click")
+	})
+
+	message, err := h.Transport.SendMessage(context.Background(), h.ChannelID, &disgord.CreateMessageParams{Content: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.React(message.ID, "👍")
+	h.Click(message.ID, "button")
+	if fmt.Sprint(handled) != "[reaction click]" {
+		t.Errorf("expected the panics of the handlers to be reported, got %v", handled)
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	h := newHarness()
+	h.Router.CommandTimeout = time.Hour
+
+	var responseErr error
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		HandlerE: func(ctx *cmdlr2.Ctx) error {
+			<-ctx.Context.Done()
+			responseErr = ctx.ResponseText("too late")
+			return ctx.Context.Err()
+		},
+	})
+
+	var handled error
+	h.Router.ErrorHandler = func(ctx *cmdlr2.Ctx, err error) {
+		handled = err
+	}
+
+	assertReplies(t, "!slow", send(h, "!slow"))
+	if !errors.Is(handled, context.DeadlineExceeded) || !errors.Is(responseErr, context.DeadlineExceeded) {
+		t.Errorf("expected the command to time out, got %v and %v", handled, responseErr)
+	}
+}
+
+func TestErrorAfterTimeout(t *testing.T) {
+	h := newHarness()
+	h.Router.CommandTimeout = 10 * time.Millisecond
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name: "slow",
+		HandlerE: func(ctx *cmdlr2.Ctx) error {
+			<-ctx.Context.Done()
+			return cmdlr2.NewUserError("That took too long.")
+		},
+	})
+
+	assertReplies(t, "!slow", send(h, "!slow"), "That took too long.")
+}
+
+func TestShutdown(t *testing.T) {
+	h := newHarness()
+
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	h.Router.RegisterCMD(echoCommand("ping"))
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name: "wait",
+		Handler: func(ctx *cmdlr2.Ctx) {
+			close(started)
+			<-ctx.Context.Done()
+			cancelled <- ctx.Context.Err()
+		},
+	})
+
+	go h.Send("!wait")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := h.Router.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the shutdown to time out, got %v", err)
+	}
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the running handler to be cancelled, got %v", err)
+	}
+
+	assertReplies(t, "!ping", send(h, "!ping"))
+	if err := h.Router.Shutdown(context.Background()); err != nil {
+		t.Errorf("expected the second shutdown to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+package cmdlr2
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// SnapshotFormat is the encoding of a storage snapshot
+type SnapshotFormat int
+
+const (
+	// SnapshotJSON encodes snapshots as JSON. Values of registered types are decoded into their type, all others are
+	// restored as json.RawMessage which is decoded by a TypedStorage.
+	SnapshotJSON SnapshotFormat = iota
+	// SnapshotGob encodes snapshots using encoding/gob. All values except basic types have to be registered.
+	SnapshotGob
+)
+
+type snapshot struct {
+	Storages map[string][]*snapshotEntry `json:"storages"`
+}
+
+type snapshotEntry struct {
+	Key string `json:"key"`
+	// Type is the name the type of the value was registered with, it is empty for unregistered types
+	Type    string      `json:"type,omitempty"`
+	Value   interface{} `json:"value"`
+	Expires *time.Time  `json:"expires,omitempty"`
+}
+
+func init() {
+	// Values loaded by a FileStorage are kept as json.RawMessage until they are read
+	gob.Register(json.RawMessage{})
+}
+
+// jsonSnapshot is used to decode JSON snapshots as the values can only be decoded once their type is known
+type jsonSnapshot struct {
+	Storages map[string][]struct {
+		Key     string          `json:"key"`
+		Type    string          `json:"type"`
+		Value   json.RawMessage `json:"value"`
+		Expires *time.Time      `json:"expires"`
+	} `json:"storages"`
+}
+
+// RegisterType registers the type of the given value under the given name, so snapshots decode values of this type
+// back into it. The name is stored in the snapshots and must not change afterwards.
+func (s *Storages) RegisterType(name string, value interface{}) error {
+	if name == "" {
+		return errors.New("the type needs a name")
+	}
+	if value == nil {
+		return fmt.Errorf("the type %s needs an example value", name)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t := reflect.TypeOf(value)
+	if registered, ok := s.types[name]; ok {
+		if registered == t {
+			return nil
+		}
+		return fmt.Errorf("the name %s is already used by %s", name, registered)
+	}
+
+	if err := registerGob(name, value); err != nil {
+		return err
+	}
+	if s.types == nil {
+		s.types = map[string]reflect.Type{}
+		s.typeNames = map[reflect.Type]string{}
+	}
+	s.types[name] = t
+	s.typeNames[t] = name
+	return nil
+}
+
+// registerGob registers the given type with gob. Registering a type again under the same name is fine, gob panics if
+// the type or the name was registered differently before, for example by another Storages.
+func registerGob(name string, value interface{}) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("the type %s can't be registered with gob: %v", name, recovered)
+		}
+	}()
+	gob.RegisterName(name, value)
+	return nil
+}
+
+// WriteSnapshot encodes the storages with the given names, all storages if none are given. Only storages which can be
+// iterated like the ObjectsMap are included, expired values are left out.
+func (s *Storages) WriteSnapshot(w io.Writer, format SnapshotFormat, names ...string) error {
+	s.mutex.RLock()
+	if len(names) == 0 {
+		for name := range s.stores {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	data := snapshot{Storages: map[string][]*snapshotEntry{}}
+	stores := make(map[string]Storage, len(names))
+	for _, name := range names {
+		if storage, ok := s.stores[name]; ok {
+			stores[name] = storage
+		}
+	}
+	s.mutex.RUnlock()
+
+	for name, storage := range stores {
+		entries, ok := snapshotEntries(storage)
+		if !ok {
+			continue
+		}
+		for _, entry := range entries {
+			entry.Type = s.typeName(entry.Value)
+		}
+		data.Storages[name] = entries
+	}
+
+	switch format {
+	case SnapshotJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(data)
+	case SnapshotGob:
+		return gob.NewEncoder(w).Encode(data)
+	}
+	return fmt.Errorf("unknown snapshot format %d", format)
+}
+
+// snapshotEntries returns the entries of the given storage if it can be iterated
+func snapshotEntries(storage Storage) ([]*snapshotEntry, bool) {
+	switch storage := storage.(type) {
+	case interface{ entries() []objectsEntry }:
+		var entries []*snapshotEntry
+		for _, entry := range storage.entries() {
+			snapshotEntry := &snapshotEntry{Key: entry.key, Value: entry.value}
+			if !entry.expires.IsZero() {
+				expires := entry.expires
+				snapshotEntry.Expires = &expires
+			}
+			entries = append(entries, snapshotEntry)
+		}
+		return entries, true
+	case interface {
+		Range(f func(key string, value interface{}) bool)
+	}:
+		var entries []*snapshotEntry
+		storage.Range(func(key string, value interface{}) bool {
+			entries = append(entries, &snapshotEntry{Key: key, Value: value})
+			return true
+		})
+		return entries, true
+	}
+	return nil, false
+}
+
+func (s *Storages) typeName(value interface{}) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.typeNames[reflect.TypeOf(value)]
+}
+
+// ReadSnapshot restores the storages of the given snapshot. The storages are initialized if they don't exist yet and
+// the values of the snapshot replace existing values with the same key.
+func (s *Storages) ReadSnapshot(r io.Reader, format SnapshotFormat) error {
+	var data snapshot
+	switch format {
+	case SnapshotJSON:
+		var raw jsonSnapshot
+		if err := json.NewDecoder(r).Decode(&raw); err != nil {
+			return err
+		}
+
+		data.Storages = make(map[string][]*snapshotEntry, len(raw.Storages))
+		for name, rawEntries := range raw.Storages {
+			entries := make([]*snapshotEntry, len(rawEntries))
+			for index, rawEntry := range rawEntries {
+				value, err := s.decodeJSON(rawEntry.Type, rawEntry.Value)
+				if err != nil {
+					return fmt.Errorf("can't decode %s in storage %s: %w", rawEntry.Key, name, err)
+				}
+				entries[index] = &snapshotEntry{Key: rawEntry.Key, Type: rawEntry.Type, Value: value, Expires: rawEntry.Expires}
+			}
+			data.Storages[name] = entries
+		}
+	case SnapshotGob:
+		if err := gob.NewDecoder(r).Decode(&data); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown snapshot format %d", format)
+	}
+
+	now := time.Now()
+	for name, entries := range data.Storages {
+		storage := s.Initialize(name)
+		for _, entry := range entries {
+			if entry.Expires == nil {
+				storage.Set(entry.Key, entry.Value)
+			} else if ttl := entry.Expires.Sub(now); ttl > 0 {
+				storage.SetWithTTL(entry.Key, entry.Value, ttl)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeJSON decodes the given value into the type registered with the given name, unknown types are kept as is
+func (s *Storages) decodeJSON(typeName string, raw json.RawMessage) (interface{}, error) {
+	s.mutex.RLock()
+	t, ok := s.types[typeName]
+	s.mutex.RUnlock()
+	if !ok {
+		return raw, nil
+	}
+
+	value := reflect.New(t)
+	if err := json.Unmarshal(raw, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+// SaveSnapshot writes a snapshot of the storages with the given names, all storages if none are given, into the given
+// file
+func (s *Storages) SaveSnapshot(path string, format SnapshotFormat, names ...string) error {
+	var data bytes.Buffer
+	if err := s.WriteSnapshot(&data, format, names...); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data.Bytes(), 0644)
+}
+
+// LoadSnapshot restores the storages from the given file. It should be called before the router handles messages. A
+// missing file isn't an error.
+func (s *Storages) LoadSnapshot(path string, format SnapshotFormat) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return s.ReadSnapshot(file, format)
+}
+
+// Autosave saves a snapshot of all storages into the given file every interval until the given context is done, then
+// it saves a last time. Errors are passed to onError if it isn't nil. The returned channel is closed after the last
+// save. An error is returned if the interval isn't positive.
+func (s *Storages) Autosave(ctx context.Context, path string, format SnapshotFormat, interval time.Duration, onError func(err error)) (<-chan struct{}, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid autosave interval %v", interval)
+	}
+
+	done := make(chan struct{})
+	save := func() {
+		if err := s.SaveSnapshot(path, format); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				save()
+			case <-ctx.Done():
+				save()
+				return
+			}
+		}
+	}()
+	return done, nil
+}
@@ -0,0 +1,179 @@
+package cmdlr2_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zackartz/cmdlr2"
+)
+
+func TestSnapshot(t *testing.T) {
+	for name, format := range map[string]cmdlr2.SnapshotFormat{"json": cmdlr2.SnapshotJSON, "gob": cmdlr2.SnapshotGob} {
+		t.Run(name, func(t *testing.T) {
+			storages := cmdlr2.NewStorages()
+			if err := storages.RegisterType("poll", &storedPoll{}); err != nil {
+				t.Fatal(err)
+			}
+			polls := storages.Initialize("polls")
+			polls.Set("lunch", &storedPoll{Question: "Pizza?", Votes: map[string]int{"yes": 2}})
+			polls.SetWithTTL("expiring", &storedPoll{Question: "Soon?"}, time.Hour)
+			polls.SetWithTTL("expired", &storedPoll{Question: "Gone?"}, time.Millisecond)
+			storages.Initialize("counters").Set("messages", 42)
+			time.Sleep(5 * time.Millisecond)
+
+			var data bytes.Buffer
+			if err := storages.WriteSnapshot(&data, format); err != nil {
+				t.Fatal(err)
+			}
+
+			restored := cmdlr2.NewStorages()
+			if err := restored.RegisterType("poll", &storedPoll{}); err != nil {
+				t.Fatal(err)
+			}
+			if err := restored.ReadSnapshot(&data, format); err != nil {
+				t.Fatal(err)
+			}
+
+			raw, _ := restored.Get("polls").Get("lunch")
+			poll, ok := raw.(*storedPoll)
+			if !ok || poll.Question != "Pizza?" || poll.Votes["yes"] != 2 {
+				t.Errorf("expected the poll to be decoded into its type, got %#v", raw)
+			}
+			if _, ok := restored.Get("polls").Get("expiring"); !ok {
+				t.Errorf("expected the expiring poll to be restored")
+			}
+			if _, ok := restored.Get("polls").Get("expired"); ok {
+				t.Errorf("expected the expired poll not to be restored")
+			}
+			if count, ok := cmdlr2.Typed[int](restored.Get("counters")).Get("messages"); !ok || count != 42 {
+				t.Errorf("expected the counter to be restored, got %d", count)
+			}
+		})
+	}
+}
+
+func TestSnapshotFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	storages := cmdlr2.NewStorages()
+	if err := storages.LoadSnapshot(path, cmdlr2.SnapshotJSON); err != nil {
+		t.Errorf("expected a missing snapshot to be ignored, got %v", err)
+	}
+
+	storages.Initialize("counters").Set("messages", 1)
+	if err := storages.SaveSnapshot(path, cmdlr2.SnapshotJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := cmdlr2.NewStorages()
+	if err := restored.LoadSnapshot(path, cmdlr2.SnapshotJSON); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Get("counters") == nil {
+		t.Errorf("expected the storage to be restored")
+	}
+}
+
+func TestSnapshotFileStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	storage, err := cmdlr2.NewFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.Set("lunch", storedPoll{Question: "Pizza?"})
+	loaded, err := cmdlr2.NewFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storages := cmdlr2.NewStorages()
+	storages.Set("polls", loaded)
+	var data bytes.Buffer
+	if err := storages.WriteSnapshot(&data, cmdlr2.SnapshotGob); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := cmdlr2.NewStorages()
+	if err := restored.ReadSnapshot(&data, cmdlr2.SnapshotGob); err != nil {
+		t.Fatal(err)
+	}
+	if poll, ok := cmdlr2.Typed[storedPoll](restored.Get("polls")).Get("lunch"); !ok || poll.Question != "Pizza?" {
+		t.Errorf("expected the loaded poll to be restored, got %+v", poll)
+	}
+}
+
+func TestSnapshotRegisterType(t *testing.T) {
+	if err := cmdlr2.NewStorages().RegisterType("poll", &storedPoll{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmdlr2.NewStorages().RegisterType("poll", &storedPoll{}); err != nil {
+		t.Errorf("expected the type to be registered again, got %v", err)
+	}
+	if err := cmdlr2.NewStorages().RegisterType("other poll", &storedPoll{}); err == nil {
+		t.Error("expected a type registered under another name to be rejected")
+	}
+}
+
+func TestAutosave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	storages := cmdlr2.NewStorages()
+	if _, err := storages.Autosave(context.Background(), path, cmdlr2.SnapshotJSON, 0, nil); err == nil {
+		t.Error("expected an interval of 0 to be rejected")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	storages.Initialize("counters").Set("messages", 1)
+	saved, err := storages.Autosave(ctx, path, cmdlr2.SnapshotJSON, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-saved
+
+	restored := cmdlr2.NewStorages()
+	if err := restored.LoadSnapshot(path, cmdlr2.SnapshotJSON); err != nil || restored.Get("counters") == nil {
+		t.Errorf("expected a last snapshot to be saved, got %v", err)
+	}
+}
+
+func TestObjectsMapAtomic(t *testing.T) {
+	storage := cmdlr2.NewObjectsMap()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			storage.Update("count", func(value interface{}, ok bool) interface{} {
+				if !ok {
+					return 1
+				}
+				return value.(int) + 1
+			})
+		}()
+	}
+	wg.Wait()
+
+	if value, _ := storage.Get("count"); value != 100 {
+		t.Errorf("expected 100 updates, got %v", value)
+	}
+	if actual, loaded := storage.GetOrSet("count", 0); !loaded || actual != 100 {
+		t.Errorf("expected GetOrSet to return the existing value, got %v", actual)
+	}
+	if storage.CompareAndSwap("count", 99, 0) || !storage.CompareAndSwap("count", 100, 0) {
+		t.Errorf("expected CompareAndSwap to only swap the current value")
+	}
+
+	storage.Set("other", 1)
+	keys := 0
+	storage.Range(func(key string, value interface{}) bool {
+		keys++
+		return true
+	})
+	if keys != 2 || storage.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d and %d", keys, storage.Len())
+	}
+}
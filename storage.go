@@ -0,0 +1,268 @@
+package cmdlr2
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Storage is a key value store used by commands to keep state between executions
+type Storage interface {
+	// Get returns the value stored for the given key, expired values are never returned
+	Get(key string) (interface{}, bool)
+	// Set stores the given value without expiry
+	Set(key string, value interface{})
+	// SetWithTTL stores the given value until the given duration passed, 0 means no expiry
+	SetWithTTL(key string, value interface{}, ttl time.Duration)
+	Delete(key string)
+}
+
+// AtomicStorage is a Storage which supports read-modify-write operations without racing other writers
+type AtomicStorage interface {
+	Storage
+	GetOrSet(key string, value interface{}) (actual interface{}, loaded bool)
+	Update(key string, update func(value interface{}, ok bool) interface{}) interface{}
+	CompareAndSwap(key string, old, new interface{}) bool
+}
+
+// Storages holds the named storages of a router
+type Storages struct {
+	// New creates the storage for the given name, an ObjectsMap limited to MaxEntries is used if it is nil
+	New func(name string) Storage
+	// MaxEntries limits the ObjectsMaps created by default, evicting the least recently used entries. 0 means no limit.
+	MaxEntries int
+
+	mutex  sync.RWMutex
+	stores map[string]Storage
+	// types and typeNames hold the type hints used to decode snapshots
+	types     map[string]reflect.Type
+	typeNames map[reflect.Type]string
+}
+
+// NewStorages creates an empty set of storages
+func NewStorages() *Storages {
+	return &Storages{stores: map[string]Storage{}}
+}
+
+// Get returns the storage with the given name or nil if it wasn't initialized yet
+func (s *Storages) Get(name string) Storage {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.stores[name]
+}
+
+// Initialize returns the storage with the given name, creating it if it doesn't exist yet
+func (s *Storages) Initialize(name string) Storage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if storage, ok := s.stores[name]; ok {
+		return storage
+	}
+
+	var storage Storage
+	if s.New != nil {
+		storage = s.New(name)
+	} else {
+		storage = NewLRUObjectsMap(s.MaxEntries)
+	}
+	if s.stores == nil {
+		s.stores = map[string]Storage{}
+	}
+	s.stores[name] = storage
+	return storage
+}
+
+// Set replaces the storage with the given name, for example by a FileStorage
+func (s *Storages) Set(name string, storage Storage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stores == nil {
+		s.stores = map[string]Storage{}
+	}
+	s.stores[name] = storage
+}
+
+// TypedStorage wraps a Storage so values don't have to be type asserted
+type TypedStorage[T any] struct {
+	Storage Storage
+}
+
+// Typed wraps the given storage into a TypedStorage holding values of type T
+func Typed[T any](storage Storage) TypedStorage[T] {
+	return TypedStorage[T]{Storage: storage}
+}
+
+// Get returns the value stored for the given key. Values of other types are treated as missing, values loaded by a
+// FileStorage are decoded into T.
+func (s TypedStorage[T]) Get(key string) (T, bool) {
+	var value T
+	raw, ok := s.Storage.Get(key)
+	if !ok {
+		return value, false
+	}
+
+	switch raw := raw.(type) {
+	case T:
+		return raw, true
+	case json.RawMessage:
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return value, false
+		}
+		return value, true
+	}
+	return value, false
+}
+
+func (s TypedStorage[T]) Set(key string, value T) {
+	s.Storage.Set(key, value)
+}
+
+func (s TypedStorage[T]) SetWithTTL(key string, value T, ttl time.Duration) {
+	s.Storage.SetWithTTL(key, value, ttl)
+}
+
+func (s TypedStorage[T]) Delete(key string) {
+	s.Storage.Delete(key)
+}
+
+type fileStorageEntry struct {
+	Value   json.RawMessage `json:"value"`
+	Expires *time.Time      `json:"expires,omitempty"`
+}
+
+// FileStorage is an ObjectsMap which persists all changes into a JSON file. Values have to be encodable as JSON, after
+// loading they are kept as json.RawMessage until they are read using a TypedStorage. Every change rewrites the whole
+// file, so it is meant for small amounts of data.
+type FileStorage struct {
+	*ObjectsMap
+	path      string
+	saveMutex sync.Mutex
+	errMutex  sync.Mutex
+	err       error
+}
+
+var _ AtomicStorage = (*FileStorage)(nil)
+
+// NewFileStorage loads the storage from the given file. If the file doesn't exist, it is created with the first
+// change.
+func NewFileStorage(path string) (*FileStorage, error) {
+	storage := &FileStorage{
+		ObjectsMap: NewObjectsMap(),
+		path:       path,
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return storage, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries map[string]fileStorageEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for key, entry := range entries {
+		var expires time.Time
+		if entry.Expires != nil {
+			expires = *entry.Expires
+			if !now.Before(expires) {
+				continue
+			}
+		}
+		storage.ObjectsMap.set(key, entry.Value, expires)
+	}
+	return storage, nil
+}
+
+func (s *FileStorage) Set(key string, value interface{}) {
+	s.ObjectsMap.Set(key, value)
+	s.setErr(s.Save())
+}
+
+func (s *FileStorage) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	s.ObjectsMap.SetWithTTL(key, value, ttl)
+	s.setErr(s.Save())
+}
+
+func (s *FileStorage) Delete(key string) {
+	s.ObjectsMap.Delete(key)
+	s.setErr(s.Save())
+}
+
+func (s *FileStorage) GetOrSet(key string, value interface{}) (interface{}, bool) {
+	actual, loaded := s.ObjectsMap.GetOrSet(key, value)
+	if !loaded {
+		s.setErr(s.Save())
+	}
+	return actual, loaded
+}
+
+func (s *FileStorage) Update(key string, update func(value interface{}, ok bool) interface{}) interface{} {
+	value := s.ObjectsMap.Update(key, update)
+	s.setErr(s.Save())
+	return value
+}
+
+func (s *FileStorage) CompareAndSwap(key string, old, new interface{}) bool {
+	swapped := s.ObjectsMap.CompareAndSwap(key, old, new)
+	if swapped {
+		s.setErr(s.Save())
+	}
+	return swapped
+}
+
+// Err returns the error of the last save, Set and Delete don't return it to satisfy the Storage interface
+func (s *FileStorage) Err() error {
+	s.errMutex.Lock()
+	defer s.errMutex.Unlock()
+
+	return s.err
+}
+
+func (s *FileStorage) setErr(err error) {
+	s.errMutex.Lock()
+	s.err = err
+	s.errMutex.Unlock()
+}
+
+// Save writes all entries which didn't expire yet into the file
+func (s *FileStorage) Save() error {
+	s.saveMutex.Lock()
+	defer s.saveMutex.Unlock()
+
+	entries := map[string]fileStorageEntry{}
+	for _, entry := range s.ObjectsMap.entries() {
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return err
+		}
+
+		fileEntry := fileStorageEntry{Value: value}
+		if !entry.expires.IsZero() {
+			expires := entry.expires
+			fileEntry.Expires = &expires
+		}
+		entries[entry.key] = fileEntry
+	}
+
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(entries); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, data.Bytes(), 0644)
+}
@@ -0,0 +1,132 @@
+package cmdlr2_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/zackartz/cmdlr2"
+)
+
+type storedPoll struct {
+	Question string
+	Votes    map[string]int
+}
+
+func TestObjectsMapExpiry(t *testing.T) {
+	storage := cmdlr2.NewObjectsMap()
+	storage.SetWithTTL("short", 1, time.Millisecond)
+	storage.Set("forever", 2)
+
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := storage.Get("short"); ok {
+		t.Errorf("expected the entry to be expired")
+	}
+	if value, ok := storage.Get("forever"); !ok || value != 2 {
+		t.Errorf("expected the entry without TTL to be kept, got %v", value)
+	}
+}
+
+func TestObjectsMapEviction(t *testing.T) {
+	storage := cmdlr2.NewLRUObjectsMap(2)
+	storage.Set("a", 1)
+	storage.Set("b", 2)
+	storage.Get("a")
+	storage.Set("c", 3)
+
+	if _, ok := storage.Get("b"); ok {
+		t.Errorf("expected the least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := storage.Get(key); !ok {
+			t.Errorf("expected %q to be kept", key)
+		}
+	}
+}
+
+func TestStorageSize(t *testing.T) {
+	tests := map[int]int{0: 10, 3: 3}
+	for size, expected := range tests {
+		router := cmdlr2.Create(&cmdlr2.Router{StorageSize: size})
+		storage := router.InitializeStorage("votes")
+		for i := 0; i < 10; i++ {
+			storage.Set(strconv.Itoa(i), i)
+		}
+
+		kept := 0
+		for i := 0; i < 10; i++ {
+			if _, ok := storage.Get(strconv.Itoa(i)); ok {
+				kept++
+			}
+		}
+		if kept != expected {
+			t.Errorf("size %d: expected %d entries to be kept, got %d", size, expected, kept)
+		}
+	}
+}
+
+func TestTypedStorage(t *testing.T) {
+	storages := cmdlr2.NewStorages()
+	pages := cmdlr2.Typed[int](storages.Initialize("pages"))
+	pages.Set("message", 3)
+	storages.Initialize("pages").Set("other", "not a page")
+
+	if page, ok := pages.Get("message"); !ok || page != 3 {
+		t.Errorf("expected page 3, got %d", page)
+	}
+	if _, ok := pages.Get("other"); ok {
+		t.Errorf("expected values of other types to be treated as missing")
+	}
+	if storages.Get("missing") != nil {
+		t.Errorf("expected storages to be created by Initialize only")
+	}
+}
+
+func TestFileStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	storage, err := cmdlr2.NewFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polls := cmdlr2.Typed[storedPoll](storage)
+	polls.Set("poll", storedPoll{Question: "Pizza?", Votes: map[string]int{"yes": 2}})
+	storage.SetWithTTL("expired", 1, time.Millisecond)
+	storage.Set("deleted", 1)
+	storage.Delete("deleted")
+	if err := storage.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	loaded, err := cmdlr2.NewFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	poll, ok := cmdlr2.Typed[storedPoll](loaded).Get("poll")
+	if !ok || poll.Question != "Pizza?" || poll.Votes["yes"] != 2 {
+		t.Errorf("expected the poll to be restored, got %+v", poll)
+	}
+	for _, key := range []string{"expired", "deleted"} {
+		if _, ok := loaded.Get(key); ok {
+			t.Errorf("expected %q not to be restored", key)
+		}
+	}
+
+	old, _ := loaded.Get("poll")
+	if loaded.CompareAndSwap("poll", json.RawMessage(`{}`), 1) {
+		t.Error("expected a different loaded value not to be swapped")
+	}
+	if !loaded.CompareAndSwap("poll", old, storedPoll{Question: "Pasta?"}) {
+		t.Error("expected the loaded value to be swapped")
+	}
+	if !loaded.CompareAndSwap("poll", storedPoll{Question: "Pasta?"}, storedPoll{Question: "Soup?"}) {
+		t.Error("expected the equal poll to be swapped")
+	}
+	if poll, _ := cmdlr2.Typed[storedPoll](loaded).Get("poll"); poll.Question != "Soup?" {
+		t.Errorf("expected the swapped poll, got %+v", poll)
+	}
+}
@@ -0,0 +1,90 @@
+package cmdlr2
+
+import (
+	"context"
+
+	"github.com/andersfylling/disgord"
+)
+
+// MessageSource delivers the incoming events of a chat platform to the router
+type MessageSource interface {
+	// CurrentUser returns the user the bot is logged in as
+	CurrentUser() (*disgord.User, error)
+
+	// OnMessageCreate registers a handler which gets called for every incoming message
+	OnMessageCreate(handler func(event *disgord.MessageCreate))
+
+	// OnMessageReactionAdd registers a handler which gets called for every reaction added to a message
+	OnMessageReactionAdd(handler func(event *disgord.MessageReactionAdd))
+}
+
+// Responder sends and manipulates messages on a chat platform
+type Responder interface {
+	// SendMessage sends a new message into the given channel
+	SendMessage(ctx context.Context, channelID disgord.Snowflake, params *disgord.CreateMessageParams) (*disgord.Message, error)
+
+	// EditMessage applies the given changes to an already sent message
+	EditMessage(ctx context.Context, channelID, messageID disgord.Snowflake, edit *MessageEdit) (*disgord.Message, error)
+
+	// DeleteMessage deletes the given message
+	DeleteMessage(ctx context.Context, channelID, messageID disgord.Snowflake) error
+
+	// AddReaction adds a reaction of the bot to the given message
+	AddReaction(ctx context.Context, channelID, messageID disgord.Snowflake, emoji string) error
+
+	// RemoveReaction removes the reaction of the given user from the given message
+	RemoveReaction(ctx context.Context, channelID, messageID disgord.Snowflake, emoji string, userID disgord.Snowflake) error
+}
+
+// Transport combines a MessageSource and a Responder. It is everything the router needs to talk to a chat platform.
+type Transport interface {
+	MessageSource
+	Responder
+}
+
+// PermissionResolver is implemented by transports which are able to resolve the permissions of guild members
+type PermissionResolver interface {
+	// MemberPermissions returns the permissions of the given user in the given channel
+	MemberPermissions(ctx context.Context, guildID, channelID, userID disgord.Snowflake) (disgord.PermissionBit, error)
+}
+
+// MessageEdit describes the changes applied to a message. Nil fields are left untouched.
+type MessageEdit struct {
+	Content *string
+	Embed   *disgord.Embed
+	// Buttons replaces the buttons of the message if it isn't nil, an empty slice removes them. It is ignored by
+	// transports not implementing ComponentResponder.
+	Buttons []*Button
+}
+
+// EntitySource is implemented by transports which are able to look up users and the entities of guilds. It is used to
+// resolve arguments referring to users, members, roles, channels and emojis.
+type EntitySource interface {
+	// User returns the user with the given ID
+	User(ctx context.Context, userID disgord.Snowflake) (*disgord.User, error)
+
+	// Member returns the member of the given guild with the given user ID
+	Member(ctx context.Context, guildID, userID disgord.Snowflake) (*disgord.Member, error)
+
+	// Members returns the members of the given guild
+	Members(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Member, error)
+
+	// Roles returns the roles of the given guild
+	Roles(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Role, error)
+
+	// Channel returns the channel with the given ID
+	Channel(ctx context.Context, channelID disgord.Snowflake) (*disgord.Channel, error)
+
+	// Channels returns the channels of the given guild
+	Channels(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Channel, error)
+
+	// Emojis returns the custom emojis of the given guild
+	Emojis(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Emoji, error)
+}
+
+// MemberFetcher is implemented by entity sources whose Members may be incomplete, for example because they are served
+// from a cache. It is used if none of the returned members matches.
+type MemberFetcher interface {
+	// FetchMembers returns all members of the given guild, bypassing any cache
+	FetchMembers(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Member, error)
+}
@@ -0,0 +1,159 @@
+package cmdlr2
+
+import (
+	"context"
+
+	"github.com/andersfylling/disgord"
+)
+
+// DisgordTransport is the Transport implementation backed by a disgord client
+type DisgordTransport struct {
+	Client *disgord.Client
+}
+
+var _ Transport = (*DisgordTransport)(nil)
+var _ PermissionResolver = (*DisgordTransport)(nil)
+var _ EntitySource = (*DisgordTransport)(nil)
+
+// NewDisgordTransport creates a new transport using the given disgord client
+func NewDisgordTransport(client *disgord.Client) *DisgordTransport {
+	return &DisgordTransport{
+		Client: client,
+	}
+}
+
+func (t *DisgordTransport) CurrentUser() (*disgord.User, error) {
+	if u, err := t.Client.Cache().GetCurrentUser(); err == nil && u != nil {
+		return u, nil
+	}
+	return t.Client.CurrentUser().Get()
+}
+
+func (t *DisgordTransport) OnMessageCreate(handler func(event *disgord.MessageCreate)) {
+	t.Client.Gateway().MessageCreate(func(_ disgord.Session, h *disgord.MessageCreate) {
+		handler(h)
+	})
+}
+
+func (t *DisgordTransport) OnMessageReactionAdd(handler func(event *disgord.MessageReactionAdd)) {
+	t.Client.Gateway().MessageReactionAdd(func(_ disgord.Session, h *disgord.MessageReactionAdd) {
+		handler(h)
+	})
+}
+
+func (t *DisgordTransport) SendMessage(ctx context.Context, channelID disgord.Snowflake, params *disgord.CreateMessageParams) (*disgord.Message, error) {
+	return t.Client.Channel(channelID).WithContext(ctx).CreateMessage(params)
+}
+
+func (t *DisgordTransport) EditMessage(ctx context.Context, channelID, messageID disgord.Snowflake, edit *MessageEdit) (*disgord.Message, error) {
+	builder := t.Client.Channel(channelID).Message(messageID).WithContext(ctx).Update()
+	if edit.Content != nil {
+		builder.SetContent(*edit.Content)
+	}
+	if edit.Embed != nil {
+		builder.SetEmbed(edit.Embed)
+	}
+	return builder.Execute()
+}
+
+func (t *DisgordTransport) DeleteMessage(ctx context.Context, channelID, messageID disgord.Snowflake) error {
+	return t.Client.Channel(channelID).Message(messageID).WithContext(ctx).Delete()
+}
+
+func (t *DisgordTransport) AddReaction(ctx context.Context, channelID, messageID disgord.Snowflake, emoji string) error {
+	return t.Client.Channel(channelID).Message(messageID).Reaction(emoji).WithContext(ctx).Create()
+}
+
+func (t *DisgordTransport) RemoveReaction(ctx context.Context, channelID, messageID disgord.Snowflake, emoji string, userID disgord.Snowflake) error {
+	return t.Client.Channel(channelID).Message(messageID).Reaction(emoji).WithContext(ctx).DeleteUser(userID)
+}
+
+func (t *DisgordTransport) MemberPermissions(ctx context.Context, guildID, channelID, userID disgord.Snowflake) (disgord.PermissionBit, error) {
+	member, err := t.Client.Guild(guildID).Member(userID).WithContext(ctx).Get()
+	if err != nil {
+		return 0, err
+	}
+	channel, err := t.Client.Channel(channelID).WithContext(ctx).Get()
+	if err != nil {
+		return 0, err
+	}
+	return channel.GetPermissions(ctx, t.Client, member)
+}
+
+// The entity lookups check the cache of the client first and fall back to the REST API
+
+func (t *DisgordTransport) User(ctx context.Context, userID disgord.Snowflake) (*disgord.User, error) {
+	if user, err := t.Client.Cache().GetUser(userID); err == nil && user != nil {
+		return user, nil
+	}
+	return t.Client.User(userID).WithContext(ctx).Get(disgord.IgnoreCache)
+}
+
+func (t *DisgordTransport) Member(ctx context.Context, guildID, userID disgord.Snowflake) (*disgord.Member, error) {
+	if member, err := t.Client.Cache().GetMember(guildID, userID); err == nil && member != nil {
+		return member, nil
+	}
+	return t.Client.Guild(guildID).Member(userID).WithContext(ctx).Get(disgord.IgnoreCache)
+}
+
+// Members returns the cached members of the given guild. The cache only holds the members disgord has seen, so it is
+// usually incomplete, FetchMembers is used if none of them matches.
+func (t *DisgordTransport) Members(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Member, error) {
+	if members, err := t.Client.Cache().GetMembers(guildID, nil); err == nil && len(members) > 0 {
+		return members, nil
+	}
+	return t.FetchMembers(ctx, guildID)
+}
+
+// FetchMembers requests all members of the given guild page by page
+func (t *DisgordTransport) FetchMembers(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Member, error) {
+	const pageSize = 1000
+
+	var members []*disgord.Member
+	params := &disgord.GetMembersParams{Limit: pageSize}
+	for {
+		page, err := t.Client.Guild(guildID).WithContext(ctx).GetMembers(params, disgord.IgnoreCache)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, page...)
+		if len(page) < pageSize {
+			return members, nil
+		}
+
+		// Members are sorted by their user IDs
+		for _, member := range page {
+			if member.User != nil && member.User.ID > params.After {
+				params.After = member.User.ID
+			}
+		}
+	}
+}
+
+func (t *DisgordTransport) Roles(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Role, error) {
+	if roles, err := t.Client.Cache().GetGuildRoles(guildID); err == nil && len(roles) > 0 {
+		return roles, nil
+	}
+	return t.Client.Guild(guildID).WithContext(ctx).GetRoles(disgord.IgnoreCache)
+}
+
+func (t *DisgordTransport) Channel(ctx context.Context, channelID disgord.Snowflake) (*disgord.Channel, error) {
+	if channel, err := t.Client.Cache().GetChannel(channelID); err == nil && channel != nil {
+		return channel, nil
+	}
+	return t.Client.Channel(channelID).WithContext(ctx).Get(disgord.IgnoreCache)
+}
+
+func (t *DisgordTransport) Channels(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Channel, error) {
+	if channels, err := t.Client.Cache().GetGuildChannels(guildID); err == nil && len(channels) > 0 {
+		return channels, nil
+	}
+	return t.Client.Guild(guildID).WithContext(ctx).GetChannels(disgord.IgnoreCache)
+}
+
+func (t *DisgordTransport) Emojis(ctx context.Context, guildID disgord.Snowflake) ([]*disgord.Emoji, error) {
+	if emojis, err := t.Client.Cache().GetGuildEmojis(guildID); err == nil && len(emojis) > 0 {
+		return emojis, nil
+	}
+	return t.Client.Guild(guildID).WithContext(ctx).GetEmojis(disgord.IgnoreCache)
+}
@@ -0,0 +1,509 @@
+package cmdlr2
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+// DefaultAPIURL is the base URL of the Discord REST API used by the DisgordInteractionTransport
+const DefaultAPIURL = "https://discord.com/api/v9"
+
+// interactionTokenTTL is the time an interaction token can be used for follow up messages
+const interactionTokenTTL = 15 * time.Minute
+
+// maxInteractionRequestSize limits the size of incoming interactions
+const maxInteractionRequestSize = 1 << 20
+
+// Interaction callback types
+const (
+	callbackPong                   = 1
+	callbackChannelMessage         = 4
+	callbackDeferredChannelMessage = 5
+	callbackDeferredUpdateMessage  = 6
+	callbackUpdateMessage          = 7
+	callbackAutocompleteResult     = 8
+)
+
+// errInteractionAnswered is returned if an interaction which was deferred already gets an autocomplete response
+var errInteractionAnswered = errors.New("the interaction was answered already")
+
+// DisgordInteractionTransport extends the DisgordTransport by interactions. disgord doesn't deliver interactions over
+// the gateway, so the transport receives them as an http.Handler which has to be reachable at the interactions
+// endpoint URL of the application. All responses are sent using the REST API.
+//
+//	transport, err := cmdlr2.NewDisgordInteractionTransport(client, applicationID, botToken, publicKey)
+//	router.InitializeTransport(transport)
+//	http.Handle("/interactions", transport)
+type DisgordInteractionTransport struct {
+	*DisgordTransport
+	ApplicationID disgord.Snowflake
+	// BotToken authorizes the requests to the REST API
+	BotToken string
+	// PublicKey verifies the signatures of the incoming interactions
+	PublicKey ed25519.PublicKey
+	// HTTPClient sends the requests to the REST API, it defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// APIURL is the base URL of the REST API, it defaults to DefaultAPIURL
+	APIURL string
+	// ResponseTimeout is the time an incoming interaction waits for its response before it is deferred, Discord
+	// requires an answer within 3 seconds. It defaults to 2 seconds.
+	ResponseTimeout time.Duration
+
+	mutex    sync.Mutex
+	handlers []func(interaction *Interaction)
+	pending  map[disgord.Snowflake]*pendingInteraction
+}
+
+var _ InteractionSource = (*DisgordInteractionTransport)(nil)
+var _ AutocompleteResponder = (*DisgordInteractionTransport)(nil)
+var _ ComponentResponder = (*DisgordInteractionTransport)(nil)
+var _ http.Handler = (*DisgordInteractionTransport)(nil)
+
+// NewDisgordInteractionTransport creates a new transport using the given disgord client. The public key is the hex
+// encoded key shown in the developer portal.
+func NewDisgordInteractionTransport(client *disgord.Client, applicationID disgord.Snowflake, botToken, publicKey string) (*DisgordInteractionTransport, error) {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key")
+	}
+
+	return &DisgordInteractionTransport{
+		DisgordTransport: NewDisgordTransport(client),
+		ApplicationID:    applicationID,
+		BotToken:         botToken,
+		PublicKey:        key,
+	}, nil
+}
+
+// interactionCallback is the initial response to an interaction
+type interactionCallback struct {
+	Type int         `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// pendingInteraction is an interaction whose endpoint request waits for the initial response
+type pendingInteraction struct {
+	response chan *interactionCallback
+	// written is closed once the response was written
+	written chan struct{}
+	// deferred is set once the endpoint answered with a deferred response, it is guarded by the mutex of the transport
+	deferred bool
+}
+
+func (t *DisgordInteractionTransport) OnInteractionCreate(handler func(interaction *Interaction)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.handlers = append(t.handlers, handler)
+}
+
+// ServeHTTP receives an interaction from Discord. Requests without a valid signature are rejected. The interaction is
+// answered with the first response of its handlers, if there is none within the ResponseTimeout, it is deferred and
+// the response is sent using the REST API afterwards.
+func (t *DisgordInteractionTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxInteractionRequestSize))
+	if err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if !t.verify(r.Header.Get("X-Signature-Ed25519"), r.Header.Get("X-Signature-Timestamp"), body) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var interaction Interaction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+	if interaction.Type == InteractionPing {
+		writeCallback(w, &interactionCallback{Type: callbackPong})
+		return
+	}
+
+	pending := &pendingInteraction{
+		response: make(chan *interactionCallback, 1),
+		written:  make(chan struct{}),
+	}
+	t.mutex.Lock()
+	if t.pending == nil {
+		t.pending = map[disgord.Snowflake]*pendingInteraction{}
+	}
+	t.pending[interaction.ID] = pending
+	t.mutex.Unlock()
+	defer close(pending.written)
+
+	go t.emit(&interaction)
+
+	timer := time.NewTimer(t.responseTimeout())
+	defer timer.Stop()
+
+	select {
+	case callback := <-pending.response:
+		writeCallback(w, callback)
+		return
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+
+	t.mutex.Lock()
+	current, ok := t.pending[interaction.ID]
+	if ok && current == pending {
+		pending.deferred = true
+	}
+	t.mutex.Unlock()
+	if !ok || current != pending {
+		// The response was sent right now
+		writeCallback(w, <-pending.response)
+		return
+	}
+
+	// Forget the interaction once its token expired, even if its handlers never respond
+	time.AfterFunc(interactionTokenTTL, func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+
+		if t.pending[interaction.ID] == pending {
+			delete(t.pending, interaction.ID)
+		}
+	})
+	writeCallback(w, deferredCallback(&interaction))
+}
+
+func (t *DisgordInteractionTransport) verify(signature, timestamp string, body []byte) bool {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil || len(decoded) != ed25519.SignatureSize || len(t.PublicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(t.PublicKey, append([]byte(timestamp), body...), decoded)
+}
+
+func (t *DisgordInteractionTransport) emit(interaction *Interaction) {
+	t.mutex.Lock()
+	handlers := t.handlers
+	t.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(interaction)
+	}
+}
+
+func (t *DisgordInteractionTransport) responseTimeout() time.Duration {
+	if t.ResponseTimeout > 0 {
+		return t.ResponseTimeout
+	}
+	return 2 * time.Second
+}
+
+// deferredCallback acknowledges the given interaction without answering it yet
+func deferredCallback(interaction *Interaction) *interactionCallback {
+	switch interaction.Type {
+	case InteractionMessageComponent:
+		return &interactionCallback{Type: callbackDeferredUpdateMessage}
+	case InteractionAutocomplete:
+		// Autocompletions can't be deferred
+		return &interactionCallback{Type: callbackAutocompleteResult, Data: map[string]interface{}{"choices": []interface{}{}}}
+	default:
+		return &interactionCallback{Type: callbackDeferredChannelMessage}
+	}
+}
+
+func writeCallback(w http.ResponseWriter, callback *interactionCallback) {
+	data, err := json.Marshal(callback)
+	if err != nil {
+		http.Error(w, "invalid response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	_, _ = w.Write(data)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// respond sends the initial response to the given interaction. It is written into the pending endpoint request if
+// there is one, otherwise it is sent using the REST API. It returns whether the interaction was deferred before, the
+// response then has to be sent in another way by the caller.
+func (t *DisgordInteractionTransport) respond(ctx context.Context, interaction *Interaction, callback *interactionCallback) (bool, error) {
+	t.mutex.Lock()
+	pending, ok := t.pending[interaction.ID]
+	if ok {
+		delete(t.pending, interaction.ID)
+	}
+	t.mutex.Unlock()
+
+	if ok && pending.deferred {
+		return true, nil
+	}
+	if ok {
+		pending.response <- callback
+		select {
+		case <-pending.written:
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+
+	path := fmt.Sprintf("/interactions/%v/%s/callback", interaction.ID, interaction.Token)
+	return false, t.request(ctx, http.MethodPost, path, callback, nil)
+}
+
+func (t *DisgordInteractionTransport) SetApplicationCommands(ctx context.Context, guildID disgord.Snowflake, commands []*ApplicationCommand) error {
+	path := fmt.Sprintf("/applications/%v/commands", t.ApplicationID)
+	if !guildID.IsZero() {
+		path = fmt.Sprintf("/applications/%v/guilds/%v/commands", t.ApplicationID, guildID)
+	}
+	if commands == nil {
+		commands = []*ApplicationCommand{}
+	}
+	return t.request(ctx, http.MethodPut, path, commands, nil)
+}
+
+func (t *DisgordInteractionTransport) CreateInteractionResponse(ctx context.Context, interaction *Interaction, params *disgord.CreateMessageParams) (*disgord.Message, error) {
+	payload := messagePayload(params, nil)
+	deferred, err := t.respond(ctx, interaction, &interactionCallback{Type: callbackChannelMessage, Data: payload})
+	if err != nil {
+		return nil, err
+	}
+
+	var message disgord.Message
+	if deferred {
+		// The deferred response shows a loading state which is replaced by the actual response
+		err = t.request(ctx, http.MethodPatch, t.originalPath(interaction), payload, &message)
+		return &message, err
+	}
+
+	// Discord might still be processing the response, so the message is fetched a few times
+	for attempt := 1; ; attempt++ {
+		err = t.request(ctx, http.MethodGet, t.originalPath(interaction), nil, &message)
+		var apiError *APIError
+		if !errors.As(err, &apiError) || apiError.StatusCode != http.StatusNotFound || attempt == 3 {
+			return &message, err
+		}
+		if err := sleep(ctx, time.Duration(attempt)*250*time.Millisecond); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *DisgordInteractionTransport) CreateFollowupMessage(ctx context.Context, interaction *Interaction, params *disgord.CreateMessageParams) (*disgord.Message, error) {
+	var message disgord.Message
+	path := fmt.Sprintf("/webhooks/%v/%s", t.ApplicationID, interaction.Token)
+	if err := t.request(ctx, http.MethodPost, path, messagePayload(params, nil), &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (t *DisgordInteractionTransport) CreateAutocompleteResponse(ctx context.Context, interaction *Interaction, choices []*ApplicationCommandOptionChoice) error {
+	if choices == nil {
+		choices = []*ApplicationCommandOptionChoice{}
+	}
+	deferred, err := t.respond(ctx, interaction, &interactionCallback{
+		Type: callbackAutocompleteResult,
+		Data: map[string]interface{}{"choices": choices},
+	})
+	if err == nil && deferred {
+		return errInteractionAnswered
+	}
+	return err
+}
+
+func (t *DisgordInteractionTransport) originalPath(interaction *Interaction) string {
+	return fmt.Sprintf("/webhooks/%v/%s/messages/@original", t.ApplicationID, interaction.Token)
+}
+
+func (t *DisgordInteractionTransport) SendMessageWithButtons(ctx context.Context, channelID disgord.Snowflake, params *disgord.CreateMessageParams, buttons []*Button) (*disgord.Message, error) {
+	var message disgord.Message
+	path := fmt.Sprintf("/channels/%v/messages", channelID)
+	if err := t.request(ctx, http.MethodPost, path, messagePayload(params, buttons), &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (t *DisgordInteractionTransport) UpdateComponentMessage(ctx context.Context, interaction *Interaction, edit *MessageEdit) error {
+	callback := &interactionCallback{Type: callbackDeferredUpdateMessage}
+	if edit != nil {
+		callback = &interactionCallback{Type: callbackUpdateMessage, Data: editPayload(edit)}
+	}
+	deferred, err := t.respond(ctx, interaction, callback)
+	if err != nil || !deferred || edit == nil {
+		return err
+	}
+
+	// The click was acknowledged already, so the message is edited through the interaction
+	return t.request(ctx, http.MethodPatch, t.originalPath(interaction), editPayload(edit), nil)
+}
+
+// EditMessage edits the given message using the REST API if its buttons change, disgord doesn't support them
+func (t *DisgordInteractionTransport) EditMessage(ctx context.Context, channelID, messageID disgord.Snowflake, edit *MessageEdit) (*disgord.Message, error) {
+	if edit.Buttons == nil {
+		return t.DisgordTransport.EditMessage(ctx, channelID, messageID, edit)
+	}
+
+	var message disgord.Message
+	path := fmt.Sprintf("/channels/%v/messages/%v", channelID, messageID)
+	if err := t.request(ctx, http.MethodPatch, path, editPayload(edit), &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// messagePayload converts the given message into the body of a REST request. Buttons are only added if they aren't
+// nil.
+func messagePayload(params *disgord.CreateMessageParams, buttons []*Button) map[string]interface{} {
+	payload := map[string]interface{}{"content": params.Content}
+	if params.Embed != nil {
+		payload["embeds"] = []*disgord.Embed{params.Embed}
+	}
+	if buttons != nil {
+		payload["components"] = actionRows(buttons)
+	}
+	return payload
+}
+
+// editPayload converts the given changes into the body of a REST request
+func editPayload(edit *MessageEdit) map[string]interface{} {
+	payload := map[string]interface{}{}
+	if edit.Content != nil {
+		payload["content"] = *edit.Content
+	}
+	if edit.Embed != nil {
+		payload["embeds"] = []*disgord.Embed{edit.Embed}
+	}
+	if edit.Buttons != nil {
+		payload["components"] = actionRows(edit.Buttons)
+	}
+	return payload
+}
+
+// componentButton is a button as sent to Discord
+type componentButton struct {
+	Type int `json:"type"`
+	*Button
+}
+
+// actionRow holds up to five buttons
+type actionRow struct {
+	Type       int                `json:"type"`
+	Components []*componentButton `json:"components"`
+}
+
+// actionRows distributes the given buttons over as many action rows as needed
+func actionRows(buttons []*Button) []*actionRow {
+	const buttonsPerRow = 5
+
+	rows := []*actionRow{}
+	for index, button := range buttons {
+		if index%buttonsPerRow == 0 {
+			rows = append(rows, &actionRow{Type: 1})
+		}
+		row := rows[len(rows)-1]
+		row.Components = append(row.Components, &componentButton{Type: 2, Button: button})
+	}
+	return rows
+}
+
+// APIError is returned by the DisgordInteractionTransport if the REST API rejects a request
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// request sends a request to the REST API and decodes the response into the given result if it isn't nil. Rate
+// limited requests are retried after the time given by Discord.
+func (t *DisgordInteractionTransport) request(ctx context.Context, method, path string, body, result interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	apiURL := t.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	client := t.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	for attempt := 1; ; attempt++ {
+		request, err := http.NewRequestWithContext(ctx, method, apiURL+path, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Authorization", "Bot "+t.BotToken)
+		if body != nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+
+		response, err := client.Do(request)
+		if err != nil {
+			return err
+		}
+		responseBody, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if response.StatusCode == http.StatusTooManyRequests && attempt < 3 {
+			retryAfter, _ := strconv.ParseFloat(response.Header.Get("Retry-After"), 64)
+			if err := sleep(ctx, time.Duration(retryAfter*float64(time.Second))); err != nil {
+				return err
+			}
+			continue
+		}
+		if response.StatusCode >= http.StatusMultipleChoices {
+			return &APIError{Method: method, Path: path, StatusCode: response.StatusCode, Body: string(responseBody)}
+		}
+		if result == nil || len(responseBody) == 0 {
+			return nil
+		}
+		return json.Unmarshal(responseBody, result)
+	}
+}
+
+// sleep waits for the given duration or until the given context is done
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,201 @@
+package cmdlr2_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andersfylling/disgord"
+	"github.com/zackartz/cmdlr2"
+)
+
+type apiRequest struct {
+	method, path string
+	body         map[string]interface{}
+}
+
+// interactionHarness serves a DisgordInteractionTransport against a fake REST API
+type interactionHarness struct {
+	t         *testing.T
+	router    *cmdlr2.Router
+	transport *cmdlr2.DisgordInteractionTransport
+	key       ed25519.PrivateKey
+
+	mutex    sync.Mutex
+	requests []apiRequest
+}
+
+func newInteractionHarness(t *testing.T) *interactionHarness {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &interactionHarness{t: t, key: private}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bot token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		request := apiRequest{method: r.Method, path: r.URL.Path}
+		_ = json.NewDecoder(r.Body).Decode(&request.body)
+		h.mutex.Lock()
+		h.requests = append(h.requests, request)
+		h.mutex.Unlock()
+		_, _ = w.Write([]byte(`{"id":"100","content":"original"}`))
+	}))
+	t.Cleanup(api.Close)
+
+	h.transport = &cmdlr2.DisgordInteractionTransport{
+		ApplicationID:   5,
+		BotToken:        "token",
+		PublicKey:       public,
+		APIURL:          api.URL,
+		ResponseTimeout: 50 * time.Millisecond,
+	}
+	h.router = cmdlr2.Create(&cmdlr2.Router{Prefixes: []string{"!"}})
+	h.router.Transport = h.transport
+	h.transport.OnInteractionCreate(h.router.HandleInteraction)
+	return h
+}
+
+// post sends the given interaction to the endpoint and returns the status and the decoded response
+func (h *interactionHarness) post(interaction string, sign bool) (int, map[string]interface{}) {
+	timestamp := "1600000000"
+	signature := ed25519.Sign(h.key, []byte(timestamp+interaction))
+	if !sign {
+		signature[0]++
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(interaction))
+	request.Header.Set("X-Signature-Ed25519", hex.EncodeToString(signature))
+	request.Header.Set("X-Signature-Timestamp", timestamp)
+	recorder := httptest.NewRecorder()
+	h.transport.ServeHTTP(recorder, request)
+
+	var response map[string]interface{}
+	body, _ := ioutil.ReadAll(recorder.Body)
+	_ = json.Unmarshal(body, &response)
+	return recorder.Code, response
+}
+
+// waitForRequests waits until the fake REST API received the given number of requests
+func (h *interactionHarness) waitForRequests(count int) []apiRequest {
+	h.t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		h.mutex.Lock()
+		requests := append([]apiRequest(nil), h.requests...)
+		h.mutex.Unlock()
+		if len(requests) >= count {
+			return requests
+		}
+	}
+	h.t.Fatalf("expected %d requests to the API", count)
+	return nil
+}
+
+func TestDisgordInteractionTransportVerification(t *testing.T) {
+	h := newInteractionHarness(t)
+
+	if code, _ := h.post(`{"id":"1","type":1}`, false); code != http.StatusUnauthorized {
+		t.Errorf("expected an invalid signature to be rejected, got %d", code)
+	}
+	if code, response := h.post(`{"id":"1","type":1}`, true); code != http.StatusOK || response["type"] != 1.0 {
+		t.Errorf("expected a pong, got %d %v", code, response)
+	}
+}
+
+func TestDisgordInteractionTransportResponses(t *testing.T) {
+	h := newInteractionHarness(t)
+	release := make(chan struct{})
+	h.router.RegisterCMDList([]*cmdlr2.Command{
+		{
+			Name: "ping",
+			Handler: func(ctx *cmdlr2.Ctx) {
+				_ = ctx.ResponseText("pong")
+				_ = ctx.ResponseText("again")
+			},
+		},
+		{
+			Name: "slow",
+			Handler: func(ctx *cmdlr2.Ctx) {
+				<-release
+				_ = ctx.ResponseText("finally")
+			},
+		},
+	})
+
+	code, response := h.post(`{"id":"1","type":2,"token":"abc","channel_id":"2","user":{"id":"3"},"data":{"name":"ping"}}`, true)
+	if code != http.StatusOK || response["type"] != 4.0 || response["data"].(map[string]interface{})["content"] != "pong" {
+		t.Fatalf("expected the first response in the endpoint response, got %d %v", code, response)
+	}
+	requests := h.waitForRequests(2)
+	if requests[0].method != http.MethodGet || requests[0].path != "/webhooks/5/abc/messages/@original" {
+		t.Errorf("expected the response to be fetched, got %v", requests[0])
+	}
+	if requests[1].method != http.MethodPost || requests[1].path != "/webhooks/5/abc" || requests[1].body["content"] != "again" {
+		t.Errorf("expected a follow up message, got %v", requests[1])
+	}
+
+	code, response = h.post(`{"id":"2","type":2,"token":"def","channel_id":"2","user":{"id":"3"},"data":{"name":"slow"}}`, true)
+	if code != http.StatusOK || response["type"] != 5.0 {
+		t.Fatalf("expected a slow response to be deferred, got %d %v", code, response)
+	}
+	close(release)
+	requests = h.waitForRequests(3)
+	if requests[2].method != http.MethodPatch || requests[2].path != "/webhooks/5/def/messages/@original" || requests[2].body["content"] != "finally" {
+		t.Errorf("expected the deferred response to be edited, got %v", requests[2])
+	}
+}
+
+func TestDisgordInteractionTransportCommands(t *testing.T) {
+	h := newInteractionHarness(t)
+	h.router.RegisterCMD(&cmdlr2.Command{Name: "ping", Description: "Pong", Handler: func(ctx *cmdlr2.Ctx) {}})
+
+	if err := h.router.RegisterApplicationCommands(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+	requests := h.waitForRequests(1)
+	if requests[0].method != http.MethodPut || requests[0].path != "/applications/5/guilds/7/commands" {
+		t.Errorf("expected the guild commands to be overwritten, got %v", requests[0])
+	}
+}
+
+func TestDisgordInteractionTransportComponents(t *testing.T) {
+	h := newInteractionHarness(t)
+	updated := "updated"
+	h.router.RegisterComponentHandler(func(interaction *cmdlr2.Interaction) {
+		_ = h.transport.UpdateComponentMessage(context.Background(), interaction, &cmdlr2.MessageEdit{Content: &updated})
+	})
+
+	var buttons []*cmdlr2.Button
+	for i := 0; i < 6; i++ {
+		buttons = append(buttons, &cmdlr2.Button{CustomID: strconv.Itoa(i), Label: strconv.Itoa(i)})
+	}
+	if _, err := h.transport.SendMessageWithButtons(context.Background(), 2, &disgord.CreateMessageParams{Content: "pick"}, buttons); err != nil {
+		t.Fatal(err)
+	}
+	requests := h.waitForRequests(1)
+	rows, _ := requests[0].body["components"].([]interface{})
+	if requests[0].method != http.MethodPost || requests[0].path != "/channels/2/messages" || len(rows) != 2 {
+		t.Fatalf("expected the buttons to be sent in two rows, got %v", requests[0])
+	}
+	first := rows[0].(map[string]interface{})["components"].([]interface{})
+	if len(first) != 5 || first[0].(map[string]interface{})["type"] != 2.0 || first[0].(map[string]interface{})["custom_id"] != "0" {
+		t.Errorf("expected five buttons in the first row, got %v", first)
+	}
+
+	code, response := h.post(`{"id":"3","type":3,"token":"ghi","channel_id":"2","user":{"id":"3"},"message":{"id":"100","channel_id":"2"},"data":{"custom_id":"1"}}`, true)
+	if code != http.StatusOK || response["type"] != 7.0 || response["data"].(map[string]interface{})["content"] != "updated" {
+		t.Errorf("expected the message to be updated by the endpoint response, got %d %v", code, response)
+	}
+}
@@ -0,0 +1,516 @@
+package cmdlr2
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/andersfylling/disgord"
+)
+
+// ErrUnknownMessage is returned by the MemoryTransport if a message which was never sent gets manipulated
+var ErrUnknownMessage = errors.New("unknown message")
+
+// ErrUnknownEntity is returned by the MemoryTransport if a user, member or channel which was never added is looked up
+var ErrUnknownEntity = errors.New("unknown entity")
+
+// MemoryTransport is an in-memory Transport which never talks to a real chat platform. Incoming events are injected
+// using EmitMessage and EmitReaction, every message sent through it is recorded and can be inspected afterwards.
+type MemoryTransport struct {
+	mutex               sync.RWMutex
+	user                *disgord.User
+	lastID              disgord.Snowflake
+	messageHandlers     []func(event *disgord.MessageCreate)
+	reactionHandlers    []func(event *disgord.MessageReactionAdd)
+	interactionHandlers []func(interaction *Interaction)
+	applicationCommands map[disgord.Snowflake][]*ApplicationCommand
+	responses           map[disgord.Snowflake]*Interaction
+	suggestions         map[disgord.Snowflake][]*ApplicationCommandOptionChoice
+	messages            []*disgord.Message
+	deleted             map[disgord.Snowflake]bool
+	reactions           map[disgord.Snowflake][]string
+	buttons             map[disgord.Snowflake][]*Button
+	permissions         map[disgord.Snowflake]disgord.PermissionBit
+	users               map[disgord.Snowflake]*disgord.User
+	members             map[disgord.Snowflake][]*disgord.Member
+	roles               map[disgord.Snowflake][]*disgord.Role
+	channels            []*disgord.Channel
+	emojis              map[disgord.Snowflake][]*disgord.Emoji
+}
+
+var _ Transport = (*MemoryTransport)(nil)
+var _ PermissionResolver = (*MemoryTransport)(nil)
+var _ InteractionSource = (*MemoryTransport)(nil)
+var _ EntitySource = (*MemoryTransport)(nil)
+var _ AutocompleteResponder = (*MemoryTransport)(nil)
+var _ ComponentResponder = (*MemoryTransport)(nil)
+
+// NewMemoryTransport creates a new in-memory transport acting as the given bot user
+func NewMemoryTransport(user *disgord.User) *MemoryTransport {
+	return &MemoryTransport{
+		user:                user,
+		lastID:              user.ID,
+		deleted:             map[disgord.Snowflake]bool{},
+		reactions:           map[disgord.Snowflake][]string{},
+		buttons:             map[disgord.Snowflake][]*Button{},
+		permissions:         map[disgord.Snowflake]disgord.PermissionBit{},
+		applicationCommands: map[disgord.Snowflake][]*ApplicationCommand{},
+		responses:           map[disgord.Snowflake]*Interaction{},
+		suggestions:         map[disgord.Snowflake][]*ApplicationCommandOptionChoice{},
+		users:               map[disgord.Snowflake]*disgord.User{user.ID: user},
+		members:             map[disgord.Snowflake][]*disgord.Member{},
+		roles:               map[disgord.Snowflake][]*disgord.Role{},
+		emojis:              map[disgord.Snowflake][]*disgord.Emoji{},
+	}
+}
+
+// NextID returns a new unique snowflake. It is used for every message sent through the transport.
+func (t *MemoryTransport) NextID() disgord.Snowflake {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.nextID()
+}
+
+func (t *MemoryTransport) nextID() disgord.Snowflake {
+	t.lastID++
+	return t.lastID
+}
+
+// EmitMessage passes the given message to all registered message handlers
+func (t *MemoryTransport) EmitMessage(event *disgord.MessageCreate) {
+	t.mutex.RLock()
+	handlers := t.messageHandlers
+	t.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// EmitReaction passes the given reaction to all registered reaction handlers
+func (t *MemoryTransport) EmitReaction(event *disgord.MessageReactionAdd) {
+	t.mutex.RLock()
+	handlers := t.reactionHandlers
+	t.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// EmitInteraction passes the given interaction to all registered interaction handlers
+func (t *MemoryTransport) EmitInteraction(interaction *Interaction) {
+	t.mutex.RLock()
+	handlers := t.interactionHandlers
+	t.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(interaction)
+	}
+}
+
+// ApplicationCommands returns the application commands registered for the given guild or the global ones if the guild
+// ID is zero
+func (t *MemoryTransport) ApplicationCommands(guildID disgord.Snowflake) []*ApplicationCommand {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.applicationCommands[guildID]
+}
+
+// ResponseOf returns the interaction the given message was sent in response to or nil if it is a regular message
+func (t *MemoryTransport) ResponseOf(messageID disgord.Snowflake) *Interaction {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.responses[messageID]
+}
+
+// Suggestions returns the suggestions the given autocomplete interaction was answered with
+func (t *MemoryTransport) Suggestions(interactionID disgord.Snowflake) []*ApplicationCommandOptionChoice {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.suggestions[interactionID]
+}
+
+// Messages returns all messages sent through the transport which were not deleted, in the order they were sent
+func (t *MemoryTransport) Messages() []*disgord.Message {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	messages := make([]*disgord.Message, 0, len(t.messages))
+	for _, message := range t.messages {
+		if !t.deleted[message.ID] {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// Message returns the sent message with the given ID or nil if it doesn't exist or got deleted
+func (t *MemoryTransport) Message(messageID disgord.Snowflake) *disgord.Message {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.message(messageID)
+}
+
+func (t *MemoryTransport) message(messageID disgord.Snowflake) *disgord.Message {
+	if t.deleted[messageID] {
+		return nil
+	}
+	for _, message := range t.messages {
+		if message.ID == messageID {
+			return message
+		}
+	}
+	return nil
+}
+
+// Reactions returns the reactions the bot added to the given message
+func (t *MemoryTransport) Reactions(messageID disgord.Snowflake) []string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return append([]string(nil), t.reactions[messageID]...)
+}
+
+// Buttons returns the buttons of the given message
+func (t *MemoryTransport) Buttons(messageID disgord.Snowflake) []*Button {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return append([]*Button(nil), t.buttons[messageID]...)
+}
+
+// SetPermissions sets the permissions the given user has in every channel
+func (t *MemoryTransport) SetPermissions(userID disgord.Snowflake, permissions disgord.PermissionBit) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.permissions[userID] = permissions
+}
+
+// AddUser adds a user which can be looked up afterwards
+func (t *MemoryTransport) AddUser(user *disgord.User) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.users[user.ID] = user
+}
+
+// AddMember adds a member and its user to the given guild
+func (t *MemoryTransport) AddMember(guildID disgord.Snowflake, member *disgord.Member) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	member.GuildID = guildID
+	t.users[member.User.ID] = member.User
+	t.members[guildID] = append(t.members[guildID], member)
+}
+
+// AddRole adds a role to the given guild
+func (t *MemoryTransport) AddRole(guildID disgord.Snowflake, role *disgord.Role) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.roles[guildID] = append(t.roles[guildID], role)
+}
+
+// AddChannel adds a channel to the guild set in the channel
+func (t *MemoryTransport) AddChannel(channel *disgord.Channel) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.channels = append(t.channels, channel)
+}
+
+// AddEmoji adds a custom emoji to the given guild
+func (t *MemoryTransport) AddEmoji(guildID disgord.Snowflake, emoji *disgord.Emoji) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.emojis[guildID] = append(t.emojis[guildID], emoji)
+}
+
+func (t *MemoryTransport) CurrentUser() (*disgord.User, error) {
+	return t.user, nil
+}
+
+func (t *MemoryTransport) OnMessageCreate(handler func(event *disgord.MessageCreate)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.messageHandlers = append(t.messageHandlers, handler)
+}
+
+func (t *MemoryTransport) OnMessageReactionAdd(handler func(event *disgord.MessageReactionAdd)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.reactionHandlers = append(t.reactionHandlers, handler)
+}
+
+func (t *MemoryTransport) SendMessage(ctx context.Context, channelID disgord.Snowflake, params *disgord.CreateMessageParams) (*disgord.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.sendMessage(channelID, params), nil
+}
+
+func (t *MemoryTransport) sendMessage(channelID disgord.Snowflake, params *disgord.CreateMessageParams) *disgord.Message {
+	message := &disgord.Message{
+		ID:        t.nextID(),
+		ChannelID: channelID,
+		Author:    t.user,
+		Content:   params.Content,
+		Timestamp: disgord.Time{Time: time.Now()},
+	}
+	if params.Embed != nil {
+		message.Embeds = []*disgord.Embed{params.Embed}
+	}
+
+	t.messages = append(t.messages, message)
+	return message
+}
+
+func (t *MemoryTransport) EditMessage(ctx context.Context, _, messageID disgord.Snowflake, edit *MessageEdit) (*disgord.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	message := t.message(messageID)
+	if message == nil {
+		return nil, ErrUnknownMessage
+	}
+
+	t.applyEdit(message, edit)
+	return message, nil
+}
+
+func (t *MemoryTransport) applyEdit(message *disgord.Message, edit *MessageEdit) {
+	if edit.Content != nil {
+		message.Content = *edit.Content
+	}
+	if edit.Embed != nil {
+		message.Embeds = []*disgord.Embed{edit.Embed}
+	}
+	if edit.Buttons != nil {
+		t.buttons[message.ID] = edit.Buttons
+	}
+	message.EditedTimestamp = disgord.Time{Time: time.Now()}
+}
+
+func (t *MemoryTransport) DeleteMessage(_ context.Context, _, messageID disgord.Snowflake) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.message(messageID) == nil {
+		return ErrUnknownMessage
+	}
+	t.deleted[messageID] = true
+	return nil
+}
+
+func (t *MemoryTransport) AddReaction(_ context.Context, _, messageID disgord.Snowflake, emoji string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.message(messageID) == nil {
+		return ErrUnknownMessage
+	}
+	t.reactions[messageID] = append(t.reactions[messageID], emoji)
+	return nil
+}
+
+// RemoveReaction removes the given reaction. Only reactions of the bot are recorded, so removing the reactions of
+// other users has no visible effect.
+func (t *MemoryTransport) RemoveReaction(_ context.Context, _, messageID disgord.Snowflake, emoji string, userID disgord.Snowflake) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.message(messageID) == nil {
+		return ErrUnknownMessage
+	}
+	if userID != t.user.ID {
+		return nil
+	}
+
+	reactions := t.reactions[messageID]
+	for index, reaction := range reactions {
+		if reaction == emoji {
+			t.reactions[messageID] = append(reactions[:index:index], reactions[index+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (t *MemoryTransport) MemberPermissions(_ context.Context, _, _, userID disgord.Snowflake) (disgord.PermissionBit, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.permissions[userID], nil
+}
+
+func (t *MemoryTransport) OnInteractionCreate(handler func(interaction *Interaction)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.interactionHandlers = append(t.interactionHandlers, handler)
+}
+
+func (t *MemoryTransport) SetApplicationCommands(_ context.Context, guildID disgord.Snowflake, commands []*ApplicationCommand) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.applicationCommands[guildID] = commands
+	return nil
+}
+
+func (t *MemoryTransport) CreateInteractionResponse(ctx context.Context, interaction *Interaction, params *disgord.CreateMessageParams) (*disgord.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	message := t.sendMessage(interaction.ChannelID, params)
+	t.responses[message.ID] = interaction
+	return message, nil
+}
+
+func (t *MemoryTransport) CreateFollowupMessage(ctx context.Context, interaction *Interaction, params *disgord.CreateMessageParams) (*disgord.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	message := t.sendMessage(interaction.ChannelID, params)
+	t.responses[message.ID] = interaction
+	return message, nil
+}
+
+func (t *MemoryTransport) CreateAutocompleteResponse(ctx context.Context, interaction *Interaction, choices []*ApplicationCommandOptionChoice) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.suggestions[interaction.ID] = choices
+	return nil
+}
+
+func (t *MemoryTransport) SendMessageWithButtons(ctx context.Context, channelID disgord.Snowflake, params *disgord.CreateMessageParams, buttons []*Button) (*disgord.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	message := t.sendMessage(channelID, params)
+	t.buttons[message.ID] = buttons
+	return message, nil
+}
+
+func (t *MemoryTransport) UpdateComponentMessage(ctx context.Context, interaction *Interaction, edit *MessageEdit) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	message := t.message(interaction.Message.ID)
+	if message == nil {
+		return ErrUnknownMessage
+	}
+	if edit != nil {
+		t.applyEdit(message, edit)
+	}
+	return nil
+}
+
+func (t *MemoryTransport) User(_ context.Context, userID disgord.Snowflake) (*disgord.User, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	if user, ok := t.users[userID]; ok {
+		return user, nil
+	}
+	return nil, ErrUnknownEntity
+}
+
+func (t *MemoryTransport) Member(_ context.Context, guildID, userID disgord.Snowflake) (*disgord.Member, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for _, member := range t.members[guildID] {
+		if member.User.ID == userID {
+			return member, nil
+		}
+	}
+	return nil, ErrUnknownEntity
+}
+
+func (t *MemoryTransport) Members(_ context.Context, guildID disgord.Snowflake) ([]*disgord.Member, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return append([]*disgord.Member(nil), t.members[guildID]...), nil
+}
+
+func (t *MemoryTransport) Roles(_ context.Context, guildID disgord.Snowflake) ([]*disgord.Role, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return append([]*disgord.Role(nil), t.roles[guildID]...), nil
+}
+
+func (t *MemoryTransport) Channel(_ context.Context, channelID disgord.Snowflake) (*disgord.Channel, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for _, channel := range t.channels {
+		if channel.ID == channelID {
+			return channel, nil
+		}
+	}
+	return nil, ErrUnknownEntity
+}
+
+func (t *MemoryTransport) Channels(_ context.Context, guildID disgord.Snowflake) ([]*disgord.Channel, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	var channels []*disgord.Channel
+	for _, channel := range t.channels {
+		if channel.GuildID == guildID {
+			channels = append(channels, channel)
+		}
+	}
+	return channels, nil
+}
+
+func (t *MemoryTransport) Emojis(_ context.Context, guildID disgord.Snowflake) ([]*disgord.Emoji, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return append([]*disgord.Emoji(nil), t.emojis[guildID]...), nil
+}
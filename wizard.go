@@ -0,0 +1,210 @@
+package cmdlr2
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrWizardCancelled is returned by Wizard.Run if the user answered with a cancel keyword
+	ErrWizardCancelled = errors.New("the wizard was cancelled")
+	// ErrTooManyRetries is returned by Wizard.Run if the user answered a step invalidly too often
+	ErrTooManyRetries = errors.New("too many invalid answers")
+)
+
+const (
+	// wizardStorage is the storage holding the state of unfinished wizards
+	wizardStorage = "cmdlr2_wizards"
+	// wizardStateTTL is the time an unfinished wizard can be resumed
+	wizardStateTTL = time.Hour
+)
+
+// WizardStep is a single question of a Wizard
+type WizardStep struct {
+	// Param declares the name and the type of the answer. Its choices and limits are checked like for commands, optional
+	// steps can be skipped.
+	Param    *Param
+	Question string
+	// Validate additionally checks the parsed answer. The message of a returned error is shown to the user who is
+	// asked again.
+	Validate func(ctx *Ctx, value interface{}, answers ParamValues) error
+}
+
+// Wizard asks the invoking user several questions in a row and collects the typed answers. The user can go back to
+// the previous question and cancel the wizard using keywords. The progress is kept in Router.Storage, so a wizard
+// which timed out is resumed at the same step when it is run again within an hour.
+type Wizard struct {
+	// Name identifies the progress of the wizard in the storage
+	Name  string
+	Steps []*WizardStep
+	// Timeout is the time the user has for each answer, it defaults to 5 minutes. The whole wizard is also bounded by
+	// the CommandTimeout of the router and the Timeout of the command, see Ctx.Prompt.
+	Timeout time.Duration
+	// Retries limits the invalid answers per step, 0 means no limit
+	Retries int
+	// BackKeywords, CancelKeywords and SkipKeywords default to `back`, `cancel` and `skip`
+	BackKeywords   []string
+	CancelKeywords []string
+	SkipKeywords   []string
+}
+
+// wizardState is the progress of a wizard, the raw answers are parsed again when it is resumed
+type wizardState struct {
+	Step    int               `json:"step"`
+	Answers map[string]string `json:"answers"`
+}
+
+func keywordsOrDefault(keywords []string, fallback string) []string {
+	if len(keywords) == 0 {
+		return []string{fallback}
+	}
+	return keywords
+}
+
+func isKeyword(answer string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if Equals(answer, keyword, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run asks all questions and returns the answers by the names of their parameters. It returns ErrWizardCancelled,
+// ErrTooManyRetries or the error of Prompt, for example ErrPromptTimeout, if the wizard doesn't finish. Steps without a
+// parameter, with an unknown type or with the name of another step are rejected before asking anything.
+func (w *Wizard) Run(ctx *Ctx) (ParamValues, error) {
+	if err := w.validate(ctx); err != nil {
+		return nil, err
+	}
+
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	backKeywords := keywordsOrDefault(w.BackKeywords, "back")
+	cancelKeywords := keywordsOrDefault(w.CancelKeywords, "cancel")
+
+	message := ctx.Event.Message
+	key := fmt.Sprintf("%s:%v:%v", w.Name, message.ChannelID, message.Author.ID)
+	states := Typed[wizardState](ctx.Router.InitializeStorage(wizardStorage))
+
+	// Resume at the first step whose stored answer isn't valid anymore
+	answers := ParamValues{}
+	state, _ := states.Get(key)
+	raw := map[string]string{}
+	step := 0
+	for step < state.Step && step < len(w.Steps) {
+		name := w.Steps[step].Param.Name
+		value, err := w.parse(ctx, w.Steps[step], state.Answers[name], answers)
+		if err != nil {
+			break
+		}
+		answers[name] = value
+		raw[name] = state.Answers[name]
+		step++
+	}
+
+	retries := 0
+	for step < len(w.Steps) {
+		current := w.Steps[step]
+		answer, err := ctx.Prompt(current.Question, timeout)
+		if err != nil {
+			return nil, err
+		}
+
+		content := strings.TrimSpace(answer.Content)
+		switch {
+		case isKeyword(content, cancelKeywords):
+			states.Delete(key)
+			return nil, ErrWizardCancelled
+		case isKeyword(content, backKeywords):
+			if step > 0 {
+				step--
+				delete(answers, w.Steps[step].Param.Name)
+				delete(raw, w.Steps[step].Param.Name)
+				states.SetWithTTL(key, wizardState{Step: step, Answers: copyAnswers(raw)}, wizardStateTTL)
+			}
+			retries = 0
+			continue
+		}
+
+		value, err := w.parse(ctx, current, content, answers)
+		if err != nil {
+			retries++
+			if w.Retries > 0 && retries >= w.Retries {
+				states.Delete(key)
+				return nil, ErrTooManyRetries
+			}
+			if err := ctx.ResponseText(invalidAnswerText(err)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		answers[current.Param.Name] = value
+		raw[current.Param.Name] = content
+		step++
+		retries = 0
+		states.SetWithTTL(key, wizardState{Step: step, Answers: copyAnswers(raw)}, wizardStateTTL)
+	}
+
+	states.Delete(key)
+	return answers, nil
+}
+
+// validate checks that every step has a parameter with a known type and a name no other step uses
+func (w *Wizard) validate(ctx *Ctx) error {
+	names := map[string]bool{}
+	for index, step := range w.Steps {
+		if step == nil || step.Param == nil {
+			return fmt.Errorf("step %d of wizard %q has no parameter", index+1, w.Name)
+		}
+		if names[step.Param.Name] {
+			return fmt.Errorf("parameter %q is used by several steps of wizard %q", step.Param.Name, w.Name)
+		}
+		names[step.Param.Name] = true
+		if _, ok := ctx.paramConverter(step.Param.paramType()); !ok {
+			return fmt.Errorf("unknown parameter type %q", step.Param.Type)
+		}
+	}
+	return nil
+}
+
+// parse parses and validates the given answer to the given step
+func (w *Wizard) parse(ctx *Ctx, step *WizardStep, answer string, answers ParamValues) (interface{}, error) {
+	param := step.Param
+	if param.Optional && isKeyword(answer, keywordsOrDefault(w.SkipKeywords, "skip")) {
+		return param.Default, nil
+	}
+
+	converter, _ := ctx.paramConverter(step.Param.paramType())
+	value, err := parseParam(ctx, param, converter, answer)
+	if err != nil {
+		return nil, err
+	}
+	if step.Validate != nil {
+		if err := step.Validate(ctx, value, answers); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func copyAnswers(answers map[string]string) map[string]string {
+	copied := make(map[string]string, len(answers))
+	for name, answer := range answers {
+		copied[name] = answer
+	}
+	return copied
+}
+
+func invalidAnswerText(err error) string {
+	var argumentError *ArgumentError
+	if errors.As(err, &argumentError) {
+		return fmt.Sprintf("The answer %s, please try again.", argumentError.Message)
+	}
+	return err.Error()
+}
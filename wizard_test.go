@@ -0,0 +1,131 @@
+package cmdlr2_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zackartz/cmdlr2"
+	"github.com/zackartz/cmdlr2/cmdlrtest"
+)
+
+func wizardHarness(timeout time.Duration) *cmdlrtest.Harness {
+	h := newHarness()
+	wizard := &cmdlr2.Wizard{
+		Name:    "signup",
+		Timeout: timeout,
+		Retries: 3,
+		Steps: []*cmdlr2.WizardStep{
+			{Param: &cmdlr2.Param{Name: "name"}, Question: "Name?"},
+			{Param: &cmdlr2.Param{Name: "age", Type: cmdlr2.ParamInt, Min: cmdlr2.Limit(13)}, Question: "Age?"},
+			{
+				Param:    &cmdlr2.Param{Name: "color", Optional: true, Default: "blue"},
+				Question: "Color?",
+				Validate: func(ctx *cmdlr2.Ctx, value interface{}, answers cmdlr2.ParamValues) error {
+					if value == answers.String("name") {
+						return cmdlr2.NewUserError("The color can't be your name.")
+					}
+					return nil
+				},
+			},
+		},
+	}
+	h.Router.RegisterCMD(&cmdlr2.Command{
+		Name: "signup",
+		HandlerE: func(ctx *cmdlr2.Ctx) error {
+			answers, err := wizard.Run(ctx)
+			if err != nil {
+				return err
+			}
+			return ctx.ResponseText(fmt.Sprintf("%s %d %s", answers.String("name"), answers.Int("age"), answers.String("color")))
+		},
+	})
+	return h
+}
+
+// start runs the given command in the background and waits for the first question
+func start(t *testing.T, h *cmdlrtest.Harness, content string, expected ...string) {
+	t.Helper()
+	expect(t, h, content, func() { go h.Send(content) }, expected)
+}
+
+// answer sends the given answer and waits for the next question
+func answer(t *testing.T, h *cmdlrtest.Harness, content string, expected ...string) {
+	t.Helper()
+	expect(t, h, content, func() { h.Send(content) }, expected)
+}
+
+func expect(t *testing.T, h *cmdlrtest.Harness, content string, send func(), expected []string) {
+	t.Helper()
+	sent := len(h.Transport.Messages())
+	send()
+	for deadline := time.Now().Add(time.Second); len(h.Transport.Messages()) < sent+len(expected); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%q: expected %q", content, expected)
+		}
+	}
+
+	var replies []string
+	for _, message := range h.Transport.Messages()[sent:] {
+		replies = append(replies, message.Content)
+	}
+	assertReplies(t, content, replies, expected...)
+}
+
+func TestWizard(t *testing.T) {
+	h := wizardHarness(time.Second)
+
+	start(t, h, "!signup", "Name?")
+	answer(t, h, "Alice", "Age?")
+	answer(t, h, "abc", "The answer must be a number, please try again.", "Age?")
+	answer(t, h, "10", "The answer must be at least 13, please try again.", "Age?")
+	answer(t, h, "back", "Name?")
+	answer(t, h, "Bob", "Age?")
+	answer(t, h, "20", "Color?")
+	answer(t, h, "Bob", "The color can't be your name.", "Color?")
+	answer(t, h, "skip", "Bob 20 blue")
+
+	start(t, h, "!signup", "Name?")
+	answer(t, h, "cancel", "Cancelled.")
+}
+
+func TestWizardResume(t *testing.T) {
+	h := wizardHarness(20 * time.Millisecond)
+
+	start(t, h, "!signup", "Name?")
+	answer(t, h, "Alice", "Age?", "You didn't answer in time.")
+
+	assertReplies(t, "!signup", send(h, "!signup"), "Age?", "You didn't answer in time.")
+}
+
+func TestWizardValidation(t *testing.T) {
+	tests := map[string][]*cmdlr2.WizardStep{
+		"missing parameter": {
+			{Param: &cmdlr2.Param{Name: "name"}, Question: "Name?"},
+			{Question: "Age?"},
+		},
+		"duplicate name": {
+			{Param: &cmdlr2.Param{Name: "name"}, Question: "Name?"},
+			{Param: &cmdlr2.Param{Name: "name"}, Question: "Nickname?"},
+		},
+		"unknown type": {
+			{Param: &cmdlr2.Param{Name: "color", Type: "color"}, Question: "Color?"},
+		},
+	}
+
+	for name, steps := range tests {
+		h := newHarness()
+		var runErr error
+		h.Router.RegisterCMD(&cmdlr2.Command{
+			Name: "signup",
+			Handler: func(ctx *cmdlr2.Ctx) {
+				_, runErr = (&cmdlr2.Wizard{Name: "signup", Steps: steps}).Run(ctx)
+			},
+		})
+
+		assertReplies(t, name, send(h, "!signup"))
+		if runErr == nil {
+			t.Errorf("%s: expected the wizard to be rejected", name)
+		}
+	}
+}